@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"math"
+	"time"
+)
+
+// BackoffPolicy computes how long a failed element waits before it is delivered again.
+// The first retry waits Base, every further one Multiplier times as long, but never longer than Max
+type BackoffPolicy struct {
+	Base time.Duration
+	// Multiplier defaults to 2 when it is zero. 1 keeps the delay at Base,
+	// anything below 1 is taken as 1
+	Multiplier float64
+	// Max is not applied when it is zero, the delay then stops growing at the
+	// longest time.Duration instead of overflowing
+	Max time.Duration
+}
+
+// Delay returns how long to wait after the given delivery attempt, counted from 1
+func (p BackoffPolicy) Delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	} else if multiplier < 1 {
+		multiplier = 1
+	}
+	max := p.Max
+	if max <= 0 {
+		max = math.MaxInt64
+	}
+	delay := float64(p.Base)
+	for i := 1; i < attempt && multiplier > 1 && delay < float64(max); i++ {
+		delay *= multiplier
+	}
+	if delay >= float64(max) {
+		return max
+	}
+	return time.Duration(delay)
+}
+
+// RequeueWithBackoff puts a reserved element back at the front of the queue once
+// the delay policy computes for its attempt count has passed. Until then it stays
+// reserved, so it can still be acked. An element that has been delivered too
+// often goes to the dead-letter queue right away, see WithDeadLetter
+func (q *Queue[T]) RequeueWithBackoff(handle Handle, policy BackoffPolicy) error {
+	q.mutex.Lock()
+	id := int64(handle)
+	r, ok := q.reserved[id]
+	if !ok {
+		q.unlock()
+		return ErrNotReserved
+	}
+	r.stop()
+	if q.deadLetter != nil && r.meta.deliveries >= q.maxDeliveries {
+		q.release(id)
+		q.unlock()
+		q.deadLetter.Append(r.elem)
+		return nil
+	}
+
+	retry := &reservation[T]{elem: r.elem, meta: r.meta, seq: r.seq}
+	retry.stop = afterFunc(q.clock, policy.Delay(r.meta.deliveries), func() {
+		q.expire(id, retry)
+	})
+	q.reserved[id] = retry
+	q.unlock()
+	return nil
+}
@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	p := BackoffPolicy{Base: time.Second, Multiplier: 3, Max: 20 * time.Second}
+
+	for attempt, expected := range map[int]time.Duration{
+		1: time.Second,
+		2: 3 * time.Second,
+		3: 9 * time.Second,
+		4: 20 * time.Second,
+		9: 20 * time.Second,
+	} {
+		if d := p.Delay(attempt); d != expected {
+			t.Errorf("Delay for attempt %d should be %v, it is %v", attempt, expected, d)
+		}
+	}
+	if d := (BackoffPolicy{Base: time.Second}).Delay(3); d != 4*time.Second {
+		t.Errorf("Default multiplier should be 2, delay is %v", d)
+	}
+	if d := (BackoffPolicy{Base: time.Second, Multiplier: 1}).Delay(5); d != time.Second {
+		t.Errorf("A multiplier of 1 should keep the delay constant, delay is %v", d)
+	}
+	if d := (BackoffPolicy{Base: time.Second}).Delay(200); d != math.MaxInt64 {
+		t.Errorf("Without Max the delay should stop at the longest duration, it is %v", d)
+	}
+}
+
+func TestRequeueWithBackoff(t *testing.T) {
+	clock := newFakeClock()
+	q := New[string](WithClock[string](clock), WithVisibilityTimeout[string](time.Hour))
+	q.Append("job")
+	policy := BackoffPolicy{Base: time.Second, Max: time.Minute}
+
+	_, h, _ := q.Reserve()
+	if err := q.RequeueWithBackoff(h, policy); err != nil {
+		t.Fatalf("RequeueWithBackoff should succeed, got %v", err)
+	}
+	if q.Length() != 0 {
+		t.Errorf("Element should wait for its backoff, length is %d", q.Length())
+	}
+	clock.Advance(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if item, h2, err := q.ReserveContext(ctx); err != nil || item != "job" || h2 != h {
+		t.Errorf("There should be job on reserve after the backoff, there is %v (%v)", item, err)
+	}
+	if err := q.RequeueWithBackoff(h+1, policy); err != ErrNotReserved {
+		t.Errorf("RequeueWithBackoff of an unknown handle should return ErrNotReserved, got %v", err)
+	}
+}
+
+func TestRequeueWithBackoffDeadLetter(t *testing.T) {
+	dlq := New[int]()
+	q := New[int](WithDeadLetter(dlq, 1))
+	q.Append(1)
+
+	_, h, _ := q.Reserve()
+	q.RequeueWithBackoff(h, BackoffPolicy{Base: time.Hour})
+	if dlq.Length() != 1 || q.Reserved() != 0 {
+		t.Errorf("Element should go to the dead-letter queue, it has %d", dlq.Length())
+	}
+}
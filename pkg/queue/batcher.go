@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Batcher pops the elements of a queue in batches: a batch is emitted once it
+// holds size elements or linger has passed since its first element was popped,
+// whichever comes first. This is the usual way to feed a database or a bulk API.
+// It runs in a goroutine of its own until the queue is closed and empty or Stop is called
+type Batcher[T any] struct {
+	q      *Queue[T]
+	size   int
+	linger time.Duration
+	emit   func(batch []T)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewBatcher starts popping q in batches of up to size elements and passes every
+// batch to emit, which runs in the goroutine of the Batcher. The time is read from
+// the clock of q, see WithClock. Panics if size is not positive
+func NewBatcher[T any](q *Queue[T], size int, linger time.Duration, emit func(batch []T)) *Batcher[T] {
+	if size <= 0 {
+		panic("queue: batch size must be positive")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Batcher[T]{q: q, size: size, linger: linger, emit: emit, cancel: cancel, done: make(chan struct{})}
+	go b.run(ctx)
+	return b
+}
+
+// NewBatcherChan works like NewBatcher, but sends the batches on the returned
+// channel, which is closed once the Batcher stops. The Batcher waits for every
+// batch to be received, so keep receiving until the channel is closed
+func NewBatcherChan[T any](q *Queue[T], size int, linger time.Duration) (*Batcher[T], <-chan []T) {
+	c := make(chan []T)
+	b := NewBatcher(q, size, linger, func(batch []T) {
+		c <- batch
+	})
+	go func() {
+		<-b.done
+		close(c)
+	}()
+	return b, c
+}
+
+func (b *Batcher[T]) run(ctx context.Context) {
+	defer close(b.done)
+	for {
+		batch, err := b.q.takeBatch(ctx, b.size, b.linger, true)
+		if len(batch) > 0 {
+			b.emit(batch)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Stop emits the batch that is being collected, if any, and stops the Batcher.
+// Elements still queued are left in the queue. It returns once the last batch
+// has been emitted
+func (b *Batcher[T]) Stop() {
+	b.cancel()
+	<-b.done
+}
+
+// Done returns a channel that is closed once the Batcher has stopped, either
+// because of Stop or because the queue was closed and emptied
+func (b *Batcher[T]) Done() <-chan struct{} {
+	return b.done
+}
+
+// PopBatchWait removes and returns up to max elements from the front of the queue.
+// It waits for at most maxWait, counted from the call, for the batch to fill up,
+// returning early once it holds max elements. If no element arrives in time an
+// empty batch is returned with a nil error. If ctx is done or the queue is closed
+// while the batch fills up, the elements collected so far are returned with
+// ctx.Err() or ErrClosed; the error comes without elements if nothing was collected.
+// Panics if max is not positive
+func (q *Queue[T]) PopBatchWait(ctx context.Context, max int, maxWait time.Duration) ([]T, error) {
+	if max <= 0 {
+		panic("queue: batch size must be positive")
+	}
+	return q.takeBatch(ctx, max, maxWait, false)
+}
+
+// takeBatch pops up to max elements, see PopBatchWait. With untilFirst it blocks
+// until the first element and counts wait from there, the way Batcher lingers
+func (q *Queue[T]) takeBatch(ctx context.Context, max int, wait time.Duration, untilFirst bool) ([]T, error) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	batch := make([]T, 0, max)
+	if untilFirst {
+		first, err := q.take(ctx, q.popFront)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, first)
+	}
+	deadline := q.clock.Now().Add(wait)
+	for len(batch) < max {
+		if q.count > 0 {
+			elem, _ := q.take(ctx, q.popFront)
+			batch = append(batch, elem)
+			continue
+		}
+		if q.closed {
+			if len(batch) == 0 {
+				return nil, ErrClosed
+			}
+			return batch, ErrClosed
+		}
+		d := deadline.Sub(q.clock.Now())
+		if d <= 0 {
+			break
+		}
+		if err := waitTimeout(ctx, q.grown, q.clock, d); err != nil {
+			if len(batch) == 0 {
+				return nil, err
+			}
+			return batch, err
+		}
+	}
+	return batch, nil
+}
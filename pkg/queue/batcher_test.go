@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatcherSize(t *testing.T) {
+	q := New[int]()
+	b, batches := NewBatcherChan(q, 3, time.Hour)
+	for i := 0; i < 6; i++ {
+		q.Append(i)
+	}
+
+	for i := 0; i < 2; i++ {
+		batch := <-batches
+		if len(batch) != 3 || batch[0] != 3*i {
+			t.Errorf("Batch %d should hold 3 elements from %d, it holds %v", i, 3*i, batch)
+		}
+	}
+	b.Stop()
+	if _, ok := <-batches; ok {
+		t.Error("The channel should be closed once the batcher stopped")
+	}
+}
+
+func TestBatcherLinger(t *testing.T) {
+	clock := newFakeClock()
+	q := New[int](WithClock[int](clock))
+	batches := make(chan []int, 1)
+	b := NewBatcher(q, 10, time.Second, func(batch []int) {
+		batches <- batch
+	})
+	defer b.Stop()
+
+	q.Append(1)
+	q.Append(2)
+	clock.waitForWaiters(1)
+	select {
+	case batch := <-batches:
+		t.Fatalf("The batch should wait for more elements, it was emitted with %v", batch)
+	default:
+	}
+	clock.Advance(time.Second)
+	if batch := <-batches; len(batch) != 2 {
+		t.Errorf("The batch should be emitted with 2 elements after the linger, it holds %v", batch)
+	}
+}
+
+func TestBatcherClose(t *testing.T) {
+	q := New[int]()
+	batches := make(chan []int, 1)
+	b := NewBatcher(q, 10, time.Hour, func(batch []int) {
+		batches <- batch
+	})
+
+	q.Append(1)
+	q.Close()
+	<-b.Done()
+	if batch := <-batches; len(batch) != 1 {
+		t.Errorf("The last batch should be emitted when the queue closes, it holds %v", batch)
+	}
+
+	assertPanics(t, "NewBatcher", func() {
+		NewBatcher(q, 0, time.Second, func([]int) {})
+	})
+}
+
+func TestPopBatchWait(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+
+	if batch, err := q.PopBatchWait(context.Background(), 3, time.Hour); err != nil || len(batch) != 3 || batch[2] != 2 {
+		t.Errorf("PopBatchWait should return 0 1 2 right away, it returned %v (%v)", batch, err)
+	}
+	if batch, err := q.PopBatchWait(context.Background(), 3, 10*time.Millisecond); err != nil || len(batch) != 2 {
+		t.Errorf("PopBatchWait should return 3 4 after the wait, it returned %v (%v)", batch, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Append(5)
+		q.Append(6)
+	}()
+	if batch, err := q.PopBatchWait(context.Background(), 2, time.Hour); err != nil || len(batch) != 2 || batch[0] != 5 {
+		t.Errorf("PopBatchWait should wait for 5 6, it returned %v (%v)", batch, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if batch, err := q.PopBatchWait(ctx, 2, time.Hour); err != context.DeadlineExceeded || len(batch) != 0 {
+		t.Errorf("PopBatchWait should time out without elements, it returned %v (%v)", batch, err)
+	}
+
+	q.Append(7)
+	q.Close()
+	if batch, err := q.PopBatchWait(context.Background(), 2, time.Hour); err != ErrClosed || len(batch) != 1 {
+		t.Errorf("PopBatchWait should return 7 with ErrClosed, it returned %v (%v)", batch, err)
+	}
+}
+
+func TestPopBatchWaitEmpty(t *testing.T) {
+	q := New[int]()
+	start := time.Now()
+	batch, err := q.PopBatchWait(context.Background(), 2, 10*time.Millisecond)
+	if err != nil || len(batch) != 0 {
+		t.Errorf("PopBatchWait should return an empty batch once maxWait passes, it returned %v (%v)", batch, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("PopBatchWait should give up after maxWait, it took %v", time.Since(start))
+	}
+}
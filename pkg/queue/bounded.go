@@ -0,0 +1,255 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrFull is returned when an element is rejected because a bounded queue is full
+var ErrFull = errors.New("queue: full")
+
+// OverflowPolicy decides what a bounded queue does with a new element when it is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until there is room for the new element
+	OverflowBlock OverflowPolicy = iota
+	// OverflowReject discards the new element
+	OverflowReject
+	// OverflowDropOldest evicts the element at the front of the queue to make room,
+	// turning the queue into a ring buffer that keeps the most recent elements
+	OverflowDropOldest
+	// OverflowDropNewest evicts the element at the back of the queue to make room
+	OverflowDropNewest
+)
+
+// NewBounded creates a queue that holds at most capacity elements.
+// Append and Prepend block while the queue is full.
+// It is the same as New with WithBound(capacity)
+func NewBounded[T comparable](capacity int, opts ...Option[T]) *Queue[T] {
+	return NewBoundedWithPolicy[T](capacity, OverflowBlock, opts...)
+}
+
+// NewBoundedWithPolicy creates a queue that holds at most capacity elements
+// and applies policy when an element is added to the full queue.
+// It is the same as New with WithBound(capacity) and WithOverflowPolicy(policy)
+func NewBoundedWithPolicy[T comparable](capacity int, policy OverflowPolicy, opts ...Option[T]) *Queue[T] {
+	opts = append(opts[:len(opts):len(opts)], WithBound[T](capacity), WithOverflowPolicy[T](policy))
+	return New[T](opts...)
+}
+
+// WithBound makes the queue hold at most capacity elements, what happens to an
+// element added to the full queue is set by WithOverflowPolicy. Panics if capacity is not positive
+func WithBound[T any](capacity int) Option[T] {
+	if capacity <= 0 {
+		panic("queue: capacity must be positive")
+	}
+	return func(s *settings[T]) {
+		s.capacity = capacity
+	}
+}
+
+// WithOverflowPolicy sets what a queue bounded by WithBound does with an element
+// added while it is full, the default is OverflowBlock. It has no effect on an unbounded queue
+func WithOverflowPolicy[T any](policy OverflowPolicy) Option[T] {
+	return func(s *settings[T]) {
+		s.policy = policy
+	}
+}
+
+// Returns the maximum number of elements, 0 for an unbounded queue
+func (q *Queue[T]) Capacity() int {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	return q.capacity
+}
+
+func (q *Queue[T]) full() bool {
+	return (q.capacity > 0 && q.size >= q.capacity) || (q.maxBytes > 0 && q.bytes >= q.maxBytes)
+}
+
+// waitContext blocks on c until it is signalled or ctx is done, c.L must be held
+func waitContext(ctx context.Context, c *sync.Cond) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		c.Wait()
+		return nil
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.L.Lock()
+			c.Broadcast()
+			c.L.Unlock()
+		case <-stop:
+		}
+	}()
+	c.Wait()
+	close(stop)
+	return ctx.Err()
+}
+
+// admitAppend works like admit for an element added with append, which merges it
+// into a recent queued element when coalescing, and that needs no room
+func (q *Queue[T]) admitAppend(ctx context.Context, elem T, block bool) error {
+	if _, ok := q.coalescing(elem); ok && !q.closed {
+		return nil
+	}
+	return q.admit(ctx, elem, block)
+}
+
+// admit checks whether elem can be added, applying the overflow policy of a full
+// bounded queue and rejecting duplicates with ErrDuplicate when deduplication is on
+func (q *Queue[T]) admit(ctx context.Context, elem T, block bool) error {
+	if q.duplicate(elem) {
+		return ErrDuplicate
+	}
+	if err := q.makeRoom(ctx, elem, block); err != nil {
+		return err
+	}
+	// an equal element may have been added while waiting for room
+	if q.duplicate(elem) {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// makeRoom applies the overflow policy until there is room for elem.
+// When block is false the OverflowBlock policy fails with ErrFull instead of waiting
+func (q *Queue[T]) makeRoom(ctx context.Context, elem T, block bool) error {
+	if q.closed {
+		return ErrClosed
+	}
+	if q.fits(elem) {
+		return nil
+	}
+
+	switch q.policy {
+	case OverflowReject:
+		return ErrFull
+	case OverflowDropOldest:
+		for !q.fits(elem) {
+			q.evict(q.popFront)
+		}
+		return nil
+	case OverflowDropNewest:
+		for !q.fits(elem) {
+			q.evict(q.popBack)
+		}
+		return nil
+	}
+
+	if !block {
+		return ErrFull
+	}
+	start := q.waitStart()
+	defer q.waited(start, "queue: waited long for room")
+	for !q.fits(elem) && !q.closed {
+		if err := waitContext(ctx, q.notFull); err != nil {
+			return err
+		}
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// evict drops the element that popSlot removes
+func (q *Queue[T]) evict(popSlot func() slot[T]) {
+	if q.count == 0 {
+		return
+	}
+	s := popSlot()
+	q.forget(s.id, s.elem)
+	q.removes++
+	if q.logging(LogInfo) {
+		q.log(LogInfo, "queue: evicted element", "reason", EvictCapacity)
+	}
+	if q.onEvict != nil {
+		q.evicted = append(q.evicted, eviction[T]{s.elem, EvictCapacity})
+	}
+}
+
+// AppendContext adds one element at the back of the queue. If a bounded queue
+// is full it applies the overflow policy: OverflowBlock waits until there is room,
+// returning ctx.Err() when ctx is done first, and OverflowReject returns ErrFull.
+// It returns ErrClosed if the queue is closed and ErrDuplicate if deduplication
+// is on and an equal element is already queued
+func (q *Queue[T]) AppendContext(ctx context.Context, elem T) error {
+	elem, err := q.intercept(OpAppend, elem)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if err := q.admitAppend(ctx, elem, true); err != nil {
+		return err
+	}
+	q.append(elem)
+	return nil
+}
+
+// TryAppend adds one element at the back of the queue without blocking.
+// It returns false if the element was not added because the queue is closed,
+// full with the OverflowBlock or OverflowReject policy, or already holds an equal
+// element while deduplication is on
+func (q *Queue[T]) TryAppend(elem T) bool {
+	elem, err := q.intercept(OpAppend, elem)
+	if err != nil {
+		return false
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if q.admitAppend(context.Background(), elem, false) != nil {
+		return false
+	}
+	q.append(elem)
+	return true
+}
+
+// PopContext removes and returns the element from the front of the queue.
+// If the queue is empty it blocks until an element arrives, returning ctx.Err()
+// when ctx is done first, or ErrClosed once the queue is closed and empty
+func (q *Queue[T]) PopContext(ctx context.Context) (T, error) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	return q.take(ctx, q.popFront)
+}
+
+// Full reports whether a bounded queue has no room left
+func (q *Queue[T]) Full() bool {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	return q.full()
+}
+
+// WaitNotFull blocks until the queue has room for another element. It returns
+// ErrClosed once the queue is closed and ctx.Err() once ctx is done. Another
+// producer may take the room first, so follow it with TryAppend rather than Append
+func (q *Queue[T]) WaitNotFull(ctx context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for q.full() && !q.closed {
+		if err := waitContext(ctx, q.notFull); err != nil {
+			return err
+		}
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBoundedAppendBlocks(t *testing.T) {
+	q := NewBounded[int](2)
+
+	q.Append(1)
+	q.Append(2)
+
+	done := make(chan struct{})
+	go func() {
+		q.Append(3)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Append on a full queue should block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if p := q.Pop(); p != 1 {
+		t.Errorf("There should be 1 on pop, there is %v", p)
+	}
+	<-done
+
+	if q.Length() != 2 {
+		t.Errorf("Queue length should be 2, it is %d", q.Length())
+	}
+}
+
+func TestAppendContextCancel(t *testing.T) {
+	q := NewBounded[int](1)
+	q.Append(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := q.AppendContext(ctx, 2); err != context.DeadlineExceeded {
+		t.Errorf("AppendContext should time out, got %v", err)
+	}
+	if q.Length() != 1 {
+		t.Errorf("Queue length should be 1, it is %d", q.Length())
+	}
+}
+
+func TestAppendContextClose(t *testing.T) {
+	q := NewBounded[int](1)
+	q.Append(1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		if err := q.AppendContext(context.Background(), 2); err != ErrClosed {
+			t.Errorf("AppendContext should return ErrClosed, got %v", err)
+		}
+		wg.Done()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	wg.Wait()
+}
+
+func TestPopContext(t *testing.T) {
+	q := New[int]()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := q.PopContext(ctx); err != context.Canceled {
+		t.Errorf("PopContext should be cancelled, got %v", err)
+	}
+
+	q.Append(5)
+	item, err := q.PopContext(context.Background())
+	if err != nil || item != 5 {
+		t.Errorf("There should be 5 on pop, there is %v (%v)", item, err)
+	}
+}
+
+func TestOverflowReject(t *testing.T) {
+	q := NewBoundedWithPolicy[int](2, OverflowReject)
+
+	q.Append(1)
+	q.Append(2)
+	q.Append(3)
+
+	if q.TryAppend(4) {
+		t.Error("TryAppend should fail on a full queue")
+	}
+	if err := q.AppendContext(context.Background(), 5); err != ErrFull {
+		t.Errorf("AppendContext should return ErrFull, got %v", err)
+	}
+	for _, expected := range []int{1, 2} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	q := NewBoundedWithPolicy[int](3, OverflowDropOldest)
+
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	if !q.TryAppend(10) {
+		t.Error("TryAppend should evict instead of failing")
+	}
+
+	if q.Length() != 3 {
+		t.Errorf("Queue length should be 3, it is %d", q.Length())
+	}
+	for _, expected := range []int{8, 9, 10} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	q := NewBoundedWithPolicy[int](3, OverflowDropNewest)
+
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+
+	if q.Length() != 3 {
+		t.Errorf("Queue length should be 3, it is %d", q.Length())
+	}
+	for _, expected := range []int{0, 1, 9} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestTryAppendBlockPolicy(t *testing.T) {
+	q := NewBounded[int](1)
+
+	if !q.TryAppend(1) {
+		t.Error("TryAppend should succeed on an empty queue")
+	}
+	if q.TryAppend(2) {
+		t.Error("TryAppend should fail on a full queue")
+	}
+}
+
+func TestWaitNotFull(t *testing.T) {
+	q := NewBoundedWithPolicy[int](1, OverflowReject)
+	if q.Full() {
+		t.Error("An empty queue should not be full")
+	}
+	if err := q.WaitNotFull(context.Background()); err != nil {
+		t.Errorf("WaitNotFull should return right away, got %v", err)
+	}
+	q.Append(1)
+	if !q.Full() {
+		t.Error("Queue should be full")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Pop()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.WaitNotFull(ctx); err != nil || !q.TryAppend(2) {
+		t.Errorf("WaitNotFull should return once there is room, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.WaitNotFull(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitNotFull should time out, got %v", err)
+	}
+	q.Close()
+	if err := q.WaitNotFull(context.Background()); err != ErrClosed {
+		t.Errorf("WaitNotFull should return ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+import "context"
+
+// ToChannel starts a goroutine that pops elements from the front of the queue
+// into the returned channel, so consumers can select on the queue along with
+// other channels. The channel is closed once ctx is done or the queue is closed
+// and empty. An element that was taken but not received before ctx was done
+// is put back at the front, it only counts as popped once it is received
+func (q *Queue[T]) ToChannel(ctx context.Context) <-chan T {
+	c := make(chan T)
+	go func() {
+		defer close(c)
+		for {
+			q.mutex.Lock()
+			s, err := q.pop(ctx, q.popFront)
+			var e entry[T]
+			if err == nil {
+				e = q.detach(s)
+			}
+			q.unlock()
+			if err != nil {
+				return
+			}
+
+			select {
+			case c <- e.elem:
+				q.mutex.Lock()
+				q.delivered(e)
+				q.unlock()
+			case <-ctx.Done():
+				q.mutex.Lock()
+				q.requeue(e.id, e.elem, e.meta)
+				q.unlock()
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// FromChannel appends every element received on c to the back of the queue, so
+// the queue can act as an elastic buffer behind a channel. It blocks until c is
+// closed and then returns nil, or returns ctx.Err() once ctx is done and ErrClosed
+// once the queue is closed. Elements a bounded queue rejects, and duplicates
+// while deduplication is on, are dropped
+func (q *Queue[T]) FromChannel(ctx context.Context, c <-chan T) error {
+	for {
+		select {
+		case elem, ok := <-c:
+			if !ok {
+				return nil
+			}
+			switch err := q.AppendContext(ctx, elem); err {
+			case nil, ErrFull, ErrDuplicate:
+			default:
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
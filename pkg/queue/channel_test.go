@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestToChannel(t *testing.T) {
+	hooked := 0
+	q := New[int](WithOnPop(func(int) { hooked++ }))
+	q.Append(1)
+	q.Append(2)
+	q.Append(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := q.ToChannel(ctx)
+	for _, expected := range []int{1, 2} {
+		select {
+		case item := <-c:
+			if item != expected {
+				t.Errorf("There should be %d on the channel, there is %d", expected, item)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("ToChannel should deliver queued elements")
+		}
+	}
+
+	cancel()
+	// 3 is either received before the pump notices ctx is done, or put back
+	received := 0
+	for range c {
+		received++
+	}
+	if received+q.Length() != 1 {
+		t.Errorf("No element should be lost, received %d and the queue is %v", received, q.ToSlice())
+	}
+	if pops := q.Stats().Pops; pops != uint64(2+received) || hooked != 2+received {
+		t.Errorf("Only the %d received elements should count as popped, %d did and %d were hooked", 2+received, pops, hooked)
+	}
+}
+
+func TestToChannelClose(t *testing.T) {
+	q := New[string]()
+	c := q.ToChannel(context.Background())
+
+	q.Append("last")
+	q.Close()
+
+	var received []string
+	for item := range c {
+		received = append(received, item)
+	}
+	if len(received) != 1 || received[0] != "last" {
+		t.Errorf("The channel should deliver the last element and close, got %v", received)
+	}
+}
+
+func TestFromChannel(t *testing.T) {
+	q := New[int]()
+	c := make(chan int)
+
+	done := make(chan error)
+	go func() {
+		done <- q.FromChannel(context.Background(), c)
+	}()
+	for i := 0; i < 5; i++ {
+		c <- i
+	}
+	close(c)
+	if err := <-done; err != nil {
+		t.Errorf("FromChannel should return nil once the channel is closed, got %v", err)
+	}
+	if q.Length() != 5 || q.Back() != 4 {
+		t.Errorf("Every received element should be appended, the queue is %v", q.ToSlice())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := q.FromChannel(ctx, make(chan int)); err != context.Canceled {
+		t.Errorf("FromChannel should return ctx.Err(), got %v", err)
+	}
+	q.Close()
+	c = make(chan int, 1)
+	c <- 1
+	if err := q.FromChannel(context.Background(), c); err != ErrClosed {
+		t.Errorf("FromChannel should return ErrClosed, got %v", err)
+	}
+}
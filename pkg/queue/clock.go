@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time for the time-based features of the queues, such as
+// delays and scheduled appends. Pass a fake clock with WithClock to test them
+// without sleeping
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After returns a channel that receives the current time once d has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock queues use by default, it reads the system time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// afterFunc calls f in its own goroutine once d has passed on clock.
+// The returned function cancels the call, it returns false if f has already been called.
+// On the system clock it is a time.AfterFunc, so a cancelled call leaves no timer behind
+func afterFunc(clock Clock, d time.Duration, f func()) (stop func() bool) {
+	if _, ok := clock.(systemClock); ok {
+		return time.AfterFunc(d, f).Stop
+	}
+
+	const pending, fired, stopped = 0, 1, 2
+	var state int32
+	after := clock.After(d)
+	cancel := make(chan struct{})
+	go func() {
+		select {
+		case <-after:
+			if atomic.CompareAndSwapInt32(&state, pending, fired) {
+				f()
+			}
+		case <-cancel:
+		}
+	}()
+	return func() bool {
+		if !atomic.CompareAndSwapInt32(&state, pending, stopped) {
+			return false
+		}
+		close(cancel)
+		return true
+	}
+}
+
+// waitTimeout blocks on c until it is signalled, d has passed on clock or ctx is done.
+// c.L must be held
+func waitTimeout(ctx context.Context, c *sync.Cond, clock Clock, d time.Duration) error {
+	after := clock.After(d)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-after:
+			c.L.Lock()
+			c.Broadcast()
+			c.L.Unlock()
+		case <-done:
+		}
+	}()
+	return waitContext(ctx, c)
+}
@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when it is advanced
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.waiters = append(f.waiters, fakeWaiter{f.now.Add(d), c})
+	return c
+}
+
+// Advance moves the clock forward by d and fires the waiters that became due
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.now = f.now.Add(d)
+	waiters := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.c <- f.now
+	}
+	f.waiters = waiters
+}
+
+// waitForWaiters blocks until n calls to After are pending
+func (f *fakeClock) waitForWaiters(n int) {
+	for {
+		f.mutex.Lock()
+		pending := len(f.waiters)
+		f.mutex.Unlock()
+		if pending >= n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestDelayFakeClock(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelay[string](WithClock[string](clock))
+
+	q.Append("hour", time.Hour)
+	q.Append("minute", time.Minute)
+
+	popped := make(chan string)
+	go func() {
+		for i := 0; i < 2; i++ {
+			popped <- q.Pop()
+		}
+	}()
+
+	clock.waitForWaiters(1)
+	select {
+	case p := <-popped:
+		t.Fatalf("Nothing should be due yet, popped %s", p)
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	if p := <-popped; p != "minute" {
+		t.Errorf("There should be minute on pop, there is %s", p)
+	}
+	clock.waitForWaiters(1)
+	clock.Advance(time.Hour)
+	if p := <-popped; p != "hour" {
+		t.Errorf("There should be hour on pop, there is %s", p)
+	}
+}
+
+func TestQueueAppendAtFakeClock(t *testing.T) {
+	clock := newFakeClock()
+	q := New[int](WithClock[int](clock))
+
+	q.AppendAt(1, clock.Now().Add(time.Minute))
+	stop := q.AppendAt(2, clock.Now().Add(time.Second))
+	if !stop() {
+		t.Error("stop should cancel a pending append")
+	}
+
+	clock.Advance(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if item, err := q.PopContext(ctx); err != nil || item != 1 {
+		t.Errorf("There should be 1 on pop, there is %v (%v)", item, err)
+	}
+	if stop() {
+		t.Error("stop should not cancel twice")
+	}
+}
+
+func TestAfterFuncStop(t *testing.T) {
+	fired := make(chan struct{}, 2)
+	f := func() { fired <- struct{}{} }
+
+	if stop := afterFunc(systemClock{}, 10*time.Millisecond, f); !stop() {
+		t.Error("stop should cancel a pending call on the system clock")
+	}
+	clock := newFakeClock()
+	stop := afterFunc(clock, time.Second, f)
+	clock.waitForWaiters(1)
+	clock.Advance(time.Second)
+	<-fired
+	if stop() {
+		t.Error("stop should return false once the call was made")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-fired:
+		t.Error("The cancelled call should not be made")
+	default:
+	}
+}
@@ -0,0 +1,93 @@
+package queue
+
+import "time"
+
+// coalescer merges appends of the same key that come in quick succession
+type coalescer[T any] struct {
+	window time.Duration
+	key    func(elem T) any
+	merge  func(queued, elem T) T
+	// the last element appended for every key that is still queued
+	recent map[any]recentAppend
+}
+
+type recentAppend struct {
+	id int64
+	at time.Time
+}
+
+// WithCoalesce merges an appended element into an equal one that was appended
+// less than window ago and is still queued, instead of queueing it again. This
+// collapses a storm of identical notifications into one. See WithCoalesceKey
+func WithCoalesce[T comparable](window time.Duration, merge func(queued, elem T) T) Option[T] {
+	return WithCoalesceKey(window, func(elem T) T { return elem }, merge)
+}
+
+// WithCoalesceKey merges an appended element into the element of the same key
+// that was appended less than window ago and is still queued, instead of queueing
+// it again. The queued element keeps its place and Handle and is replaced by
+// merge(queued, elem), or by elem if merge is nil. Append returns the Handle of
+// the queued element and a full bounded queue does not need room for it.
+// The window starts at the append that queued the element, so a steady stream
+// of appends is queued once per window. Only appends coalesce, the key of an
+// element must not change when it is merged
+func WithCoalesceKey[T any, K comparable](window time.Duration, key func(elem T) K, merge func(queued, elem T) T) Option[T] {
+	return func(s *settings[T]) {
+		s.coalesce = &coalescer[T]{
+			window: window,
+			key:    func(elem T) any { return key(elem) },
+			merge:  merge,
+		}
+	}
+}
+
+// coalescing returns the buffer position of the element that elem merges into,
+// if there is one
+func (q *Queue[T]) coalescing(elem T) (int, bool) {
+	if q.coalesce == nil {
+		return 0, false
+	}
+	r, ok := q.coalesce.recent[q.coalesce.key(elem)]
+	if !ok || q.clock.Now().Sub(r.at) >= q.coalesce.window {
+		return 0, false
+	}
+	// recent elements are close to the back
+	mask := len(q.buf) - 1
+	for n := 1; n <= q.count; n++ {
+		if pos := (q.tail - n) & mask; q.buf[pos].id == r.id {
+			return pos, true
+		}
+	}
+	return 0, false
+}
+
+// coalesceInto merges elem into the element at buffer position pos and returns its id
+func (q *Queue[T]) coalesceInto(pos int, elem T) int64 {
+	s := q.buf[pos]
+	merged := elem
+	if q.coalesce.merge != nil {
+		merged = q.coalesce.merge(s.elem, elem)
+	}
+	key := q.coalesce.key(elem)
+	r := q.coalesce.recent[key]
+	q.replace(pos, merged)
+	// replace forgot the element
+	q.coalesce.recent[key] = r
+	return s.id
+}
+
+// remember records that elem was appended under id and returns id
+func (q *Queue[T]) remember(id int64, elem T) int64 {
+	if q.coalesce != nil {
+		q.coalesce.recent[q.coalesce.key(elem)] = recentAppend{id, q.clock.Now()}
+	}
+	return id
+}
+
+// unremember drops elem queued under id from the recent appends
+func (q *Queue[T]) unremember(id int64, elem T) {
+	key := q.coalesce.key(elem)
+	if q.coalesce.recent[key].id == id {
+		delete(q.coalesce.recent, key)
+	}
+}
@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+type change struct {
+	record string
+	count  int
+}
+
+func TestWithCoalesce(t *testing.T) {
+	clock := newFakeClock()
+	q := New[string](WithClock[string](clock), WithCoalesce[string](time.Second, nil))
+
+	h := q.Append("a")
+	q.Append("b")
+	if h2 := q.Append("a"); h2 != h {
+		t.Errorf("The second a should coalesce into handle %d, it got %d", h, h2)
+	}
+	if q.Length() != 2 {
+		t.Errorf("Queue should hold a and b, it holds %v", q.ToSlice())
+	}
+
+	clock.Advance(time.Second)
+	q.Append("a")
+	if q.Length() != 3 {
+		t.Errorf("a should be queued again after the window, queue holds %v", q.ToSlice())
+	}
+
+	// a popped element is not coalesced into
+	q.Pop()
+	q.Pop()
+	q.Pop()
+	q.Append("b")
+	if q.Length() != 1 {
+		t.Errorf("b should be queued again after it was popped, queue holds %v", q.ToSlice())
+	}
+}
+
+func TestWithCoalesceKey(t *testing.T) {
+	merge := func(queued, elem change) change {
+		return change{queued.record, queued.count + elem.count}
+	}
+	q := NewAny[change](WithCoalesceKey(time.Minute, func(c change) string { return c.record }, merge))
+
+	q.Append(change{"x", 1})
+	q.Append(change{"y", 1})
+	q.Append(change{"x", 1})
+	q.Append(change{"x", 1})
+
+	if q.Length() != 2 {
+		t.Fatalf("Queue should hold x and y, it holds %v", q.ToSlice())
+	}
+	if c := q.Pop(); c.record != "x" || c.count != 3 {
+		t.Errorf("x should be merged 3 times, it is %v", c)
+	}
+}
+
+func TestWithCoalesceFull(t *testing.T) {
+	q := NewBoundedWithPolicy(1, OverflowReject, WithCoalesce[int](time.Minute, nil))
+	q.Append(1)
+	if !q.TryAppend(1) {
+		t.Error("An element that coalesces should not need room")
+	}
+	if q.TryAppend(2) {
+		t.Error("2 should not fit in the full queue")
+	}
+}
+
+func TestWithCoalesceBoundedPrepend(t *testing.T) {
+	q := NewBoundedWithPolicy(2, OverflowDropNewest, WithCoalesce[int](time.Minute, nil))
+	q.Append(1)
+	q.Append(2)
+	q.Prepend(2)
+	if err := q.InsertAt(1, 2); err != nil {
+		t.Fatalf("InsertAt should succeed, got %v", err)
+	}
+	if q.Length() != 2 {
+		t.Errorf("Prepend and InsertAt do not coalesce and should respect the capacity of 2, length is %d (%v)", q.Length(), q.ToSlice())
+	}
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec turns elements into bytes and back. Everything in this package that
+// stores elements outside of memory encodes them with a Codec
+type Codec[T any] interface {
+	Encode(elem T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// GobCodec encodes elements with encoding/gob, it is the default codec of a queue
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(elem T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&elem); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var elem T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&elem)
+	return elem, err
+}
+
+// JSONCodec encodes elements with encoding/json
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(elem T) ([]byte, error) {
+	return json.Marshal(elem)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var elem T
+	err := json.Unmarshal(data, &elem)
+	return elem, err
+}
+
+// WithCodec sets the codec elements are encoded with wherever they leave memory:
+// Snapshot and Restore, SQLQueue, JournalQueue and SpillQueue. GobCodec by default
+func WithCodec[T any](codec Codec[T]) Option[T] {
+	return func(s *settings[T]) {
+		s.codec = codec
+	}
+}
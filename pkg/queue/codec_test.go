@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type codecJob struct {
+	ID   int
+	Tags []string
+}
+
+func TestCodecs(t *testing.T) {
+	job := codecJob{ID: 7, Tags: []string{"a", "b"}}
+
+	for name, codec := range map[string]Codec[codecJob]{
+		"gob":  GobCodec[codecJob]{},
+		"json": JSONCodec[codecJob]{},
+	} {
+		data, err := codec.Encode(job)
+		if err != nil {
+			t.Fatalf("%s: Encode should succeed, got %v", name, err)
+		}
+		decoded, err := codec.Decode(data)
+		if err != nil || !reflect.DeepEqual(decoded, job) {
+			t.Errorf("%s: Decode should return %v, got %v (%v)", name, job, decoded, err)
+		}
+	}
+}
+
+func TestSnapshotJSONCodec(t *testing.T) {
+	q := NewAny[codecJob](WithCodec[codecJob](JSONCodec[codecJob]{}))
+	q.Append(codecJob{ID: 1})
+	q.Append(codecJob{ID: 2, Tags: []string{"x"}})
+
+	var buf bytes.Buffer
+	if err := q.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot should succeed, got %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`{"ID":2,"Tags":["x"]}`)) {
+		t.Errorf("Snapshot should contain JSON, it is %q", buf.Bytes())
+	}
+
+	restored := NewAny[codecJob](WithCodec[codecJob](JSONCodec[codecJob]{}))
+	if err := restored.Restore(&buf); err != nil || restored.Length() != 2 || restored.Back().Tags[0] != "x" {
+		t.Errorf("Restore should bring back both jobs, got %v (%v)", restored.ToSlice(), err)
+	}
+}
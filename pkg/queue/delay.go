@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// DelayQueue is a blocking queue in which every element becomes visible to Pop
+// only once its delay has elapsed. Due elements are popped in the order they
+// became due, elements that become due at the same time in the order they were appended
+type DelayQueue[T any] struct {
+	entries entryHeap[delayEntry[T]]
+	seq     uint64
+	mutex   *sync.Mutex
+	changed *sync.Cond
+	closed  bool
+	clock   Clock
+}
+
+type delayEntry[T any] struct {
+	elem T
+	due  time.Time
+	seq  uint64
+}
+
+// NewDelay creates a delay queue, of the options only WithClock applies to it
+func NewDelay[T any](opts ...Option[T]) *DelayQueue[T] {
+	q := &DelayQueue[T]{
+		entries: entryHeap[delayEntry[T]]{less: func(a, b *delayEntry[T]) bool {
+			if !a.due.Equal(b.due) {
+				return a.due.Before(b.due)
+			}
+			return a.seq < b.seq
+		}},
+		mutex: &sync.Mutex{},
+		clock: newSettings(opts).clock,
+	}
+	q.changed = sync.NewCond(q.mutex)
+	return q
+}
+
+// Returns the number of elements in queue, including those that are not due yet
+func (q *DelayQueue[T]) Length() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.entries.Len()
+}
+
+// Append adds elem, which Pop returns once delay has elapsed.
+// Appending to a closed queue is a no-op
+func (q *DelayQueue[T]) Append(elem T, delay time.Duration) {
+	q.AppendAt(elem, q.clock.Now().Add(delay))
+}
+
+// AppendAt adds elem, which Pop returns once the clock reaches at.
+// Appending to a closed queue is a no-op
+func (q *DelayQueue[T]) AppendAt(elem T, at time.Time) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.seq++
+	heap.Push(&q.entries, delayEntry[T]{elem: elem, due: at, seq: q.seq})
+	// the new element may be due earlier than the one consumers are waiting for
+	q.changed.Broadcast()
+}
+
+func (q *DelayQueue[T]) take(ctx context.Context) (T, error) {
+	for {
+		if q.entries.Len() == 0 {
+			if q.closed {
+				var zero T
+				return zero, ErrClosed
+			}
+			if err := waitContext(ctx, q.changed); err != nil {
+				var zero T
+				return zero, err
+			}
+			continue
+		}
+
+		wait := q.entries.entries[0].due.Sub(q.clock.Now())
+		if wait <= 0 {
+			entry := heap.Pop(&q.entries).(delayEntry[T])
+			return entry.elem, nil
+		}
+		if err := waitTimeout(ctx, q.changed, q.clock, wait); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// Pop removes and returns the element that is due first.
+// It blocks until an element is due. Once the queue is closed and
+// empty it returns the zero value
+func (q *DelayQueue[T]) Pop() T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	item, _ := q.take(context.Background())
+	return item
+}
+
+// Take works like Pop, but returns ErrClosed instead of the zero value
+// once the queue is closed and empty
+func (q *DelayQueue[T]) Take() (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (q *DelayQueue[T]) PopContext(ctx context.Context) (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(ctx)
+}
+
+// Close stops the queue from accepting new elements. Elements that are already
+// queued are still returned by Pop once they are due, after that Pop returns
+// the zero value and Take returns ErrClosed
+func (q *DelayQueue[T]) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.changed.Broadcast()
+	return nil
+}
+
+// AppendAt appends elem to the queue once its clock reaches at, until then
+// it is not part of the queue. The returned function cancels the append,
+// it returns false if elem has already been appended
+func (q *Queue[T]) AppendAt(elem T, at time.Time) (stop func() bool) {
+	return afterFunc(q.clock, at.Sub(q.clock.Now()), func() {
+		q.Append(elem)
+	})
+}
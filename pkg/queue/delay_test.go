@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDelayOrder(t *testing.T) {
+	q := NewDelay[string]()
+
+	start := time.Now()
+	q.Append("late", 60*time.Millisecond)
+	q.Append("early", 20*time.Millisecond)
+	q.Append("now", 0)
+	q.Append("also now", 0)
+
+	for _, expected := range []string{"now", "also now", "early", "late"} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Pop should wait for the delay, it took %v", elapsed)
+	}
+}
+
+func TestDelayWakesForEarlierElement(t *testing.T) {
+	q := NewDelay[int]()
+
+	q.Append(1, time.Hour)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Append(2, 10*time.Millisecond)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if item, err := q.PopContext(ctx); err != nil || item != 2 {
+		t.Errorf("There should be 2 on pop, there is %v (%v)", item, err)
+	}
+	if q.Length() != 1 {
+		t.Errorf("Queue length should be 1, it is %d", q.Length())
+	}
+}
+
+func TestDelayClose(t *testing.T) {
+	q := NewDelay[int]()
+
+	q.Append(1, 10*time.Millisecond)
+	q.Close()
+	q.Append(2, 0)
+
+	if item, err := q.Take(); err != nil || item != 1 {
+		t.Errorf("There should be 1 on take, there is %v (%v)", item, err)
+	}
+	if _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed, got %v", err)
+	}
+}
+
+func TestDelayAppendAt(t *testing.T) {
+	q := NewDelay[string]()
+
+	now := time.Now()
+	q.AppendAt("second", now.Add(20*time.Millisecond))
+	q.AppendAt("first", now.Add(-time.Second))
+
+	if p := q.Pop(); p != "first" {
+		t.Errorf("There should be first on pop, there is %s", p)
+	}
+	if p := q.Pop(); p != "second" || time.Since(now) < 20*time.Millisecond {
+		t.Errorf("There should be second on pop once it is due, there is %s", p)
+	}
+}
+
+func TestQueueAppendAt(t *testing.T) {
+	q := New[int]()
+
+	q.AppendAt(1, time.Now().Add(20*time.Millisecond))
+	stop := q.AppendAt(2, time.Now().Add(10*time.Millisecond))
+	if !stop() {
+		t.Error("stop should cancel a pending append")
+	}
+	if q.Length() != 0 {
+		t.Errorf("a scheduled element should not be queued yet, length is %d", q.Length())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if item, err := q.PopContext(ctx); err != nil || item != 1 {
+		t.Errorf("There should be 1 on pop, there is %v (%v)", item, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if q.Length() != 0 {
+		t.Errorf("a cancelled element should not be appended, length is %d", q.Length())
+	}
+}
@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Deque is a Chase–Lev work-stealing deque. One goroutine owns it and pushes and
+// pops at the bottom without contention, any number of thieves steal from the
+// top. A scheduler gives every worker a Deque of its own: workers take their newest
+// task with Pop and an idle worker takes the oldest task of another with Steal.
+// Push and Pop may only be called by the owner
+type Deque[T any] struct {
+	// top is where thieves steal, bottom where the owner pushes and pops. They come
+	// first to be 64-bit aligned on 32-bit platforms
+	top    int64
+	_      [56]byte
+	bottom int64
+	_      [56]byte
+	// current *dequeBuffer, replaced by a larger one when it fills up
+	buffer atomic.Value
+}
+
+// dequeBuffer is a ring of pointers to the elements, so that a thief reading a slot
+// the owner is writing gets either element whole
+type dequeBuffer struct {
+	slots []unsafe.Pointer
+}
+
+func (b *dequeBuffer) load(i int64) unsafe.Pointer {
+	return atomic.LoadPointer(&b.slots[i&int64(len(b.slots)-1)])
+}
+
+func (b *dequeBuffer) store(i int64, p unsafe.Pointer) {
+	atomic.StorePointer(&b.slots[i&int64(len(b.slots)-1)], p)
+}
+
+// NewDeque creates an empty deque, it grows as needed
+func NewDeque[T any]() *Deque[T] {
+	d := &Deque[T]{}
+	d.buffer.Store(&dequeBuffer{slots: make([]unsafe.Pointer, minQueueLen)})
+	return d
+}
+
+// Length returns the number of elements, it may be outdated by the time it is returned
+func (d *Deque[T]) Length() int {
+	n := atomic.LoadInt64(&d.bottom) - atomic.LoadInt64(&d.top)
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+// Push adds elem at the bottom. Only the owner may call it
+func (d *Deque[T]) Push(elem T) {
+	b := atomic.LoadInt64(&d.bottom)
+	t := atomic.LoadInt64(&d.top)
+	buf := d.buffer.Load().(*dequeBuffer)
+	if b-t >= int64(len(buf.slots)) {
+		grown := &dequeBuffer{slots: make([]unsafe.Pointer, len(buf.slots)<<1)}
+		for i := t; i < b; i++ {
+			grown.store(i, buf.load(i))
+		}
+		d.buffer.Store(grown)
+		buf = grown
+	}
+	buf.store(b, unsafe.Pointer(&elem))
+	atomic.StoreInt64(&d.bottom, b+1)
+}
+
+// Pop removes and returns the element at the bottom, the one pushed last.
+// It returns false if the deque is empty. Only the owner may call it
+func (d *Deque[T]) Pop() (T, bool) {
+	var zero T
+	b := atomic.LoadInt64(&d.bottom) - 1
+	buf := d.buffer.Load().(*dequeBuffer)
+	// claim the bottom element before looking at top, a thief that
+	// sees the new bottom will not take it
+	atomic.StoreInt64(&d.bottom, b)
+	t := atomic.LoadInt64(&d.top)
+	if t > b {
+		atomic.StoreInt64(&d.bottom, b+1)
+		return zero, false
+	}
+
+	elem := *(*T)(buf.load(b))
+	if t < b {
+		return elem, true
+	}
+	// the last element, race the thieves for it
+	won := atomic.CompareAndSwapInt64(&d.top, t, t+1)
+	atomic.StoreInt64(&d.bottom, b+1)
+	if !won {
+		return zero, false
+	}
+	return elem, true
+}
+
+// Steal removes and returns the element at the top, the oldest one.
+// It returns false if the deque is empty. Any goroutine may call it
+func (d *Deque[T]) Steal() (T, bool) {
+	for {
+		t := atomic.LoadInt64(&d.top)
+		b := atomic.LoadInt64(&d.bottom)
+		if t >= b {
+			var zero T
+			return zero, false
+		}
+		p := d.buffer.Load().(*dequeBuffer).load(t)
+		if atomic.CompareAndSwapInt64(&d.top, t, t+1) {
+			return *(*T)(p), true
+		}
+		// another thief or the owner took it, try the next one
+	}
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeque(t *testing.T) {
+	d := NewDeque[int]()
+	for i := 0; i < 100; i++ {
+		d.Push(i)
+	}
+	if d.Length() != 100 {
+		t.Errorf("expected 100 elements, got %d", d.Length())
+	}
+	if elem, ok := d.Pop(); !ok || elem != 99 {
+		t.Errorf("Pop should return the newest element, got %d, %v", elem, ok)
+	}
+	if elem, ok := d.Steal(); !ok || elem != 0 {
+		t.Errorf("Steal should return the oldest element, got %d, %v", elem, ok)
+	}
+	for i := 98; i > 0; i-- {
+		if elem, ok := d.Pop(); !ok || elem != i {
+			t.Fatalf("expected %d, got %d, %v", i, elem, ok)
+		}
+	}
+	if _, ok := d.Pop(); ok {
+		t.Error("Pop should fail on an empty deque")
+	}
+	if _, ok := d.Steal(); ok {
+		t.Error("Steal should fail on an empty deque")
+	}
+}
+
+func TestDequeConcurrent(t *testing.T) {
+	const n, thieves = 20000, 4
+	d := NewDeque[int]()
+	seen := make([]int32, n)
+	var taken int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt64(&taken) < n {
+				if elem, ok := d.Steal(); ok {
+					atomic.AddInt32(&seen[elem], 1)
+					atomic.AddInt64(&taken, 1)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		d.Push(i)
+		if i%3 == 0 {
+			if elem, ok := d.Pop(); ok {
+				atomic.AddInt32(&seen[elem], 1)
+				atomic.AddInt64(&taken, 1)
+			}
+		}
+	}
+	for {
+		elem, ok := d.Pop()
+		if !ok {
+			break
+		}
+		atomic.AddInt32(&seen[elem], 1)
+		atomic.AddInt64(&taken, 1)
+	}
+	wg.Wait()
+
+	for elem, count := range seen {
+		if count != 1 {
+			t.Fatalf("element %d was taken %d times", elem, count)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// GobEncode encodes the queued elements from front to back, so a queue can be
+// part of a value that is persisted with encoding/gob
+func (q *Queue[T]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(q.ToSlice()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the elements of the queue with the ones GobEncode encoded.
+// A queue allocated by the decoder cannot look elements up by value, like one created by NewAny
+func (q *Queue[T]) GobDecode(data []byte) error {
+	var elems []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&elems); err != nil {
+		return err
+	}
+	return q.load(elems)
+}
+
+// MarshalJSON encodes the queued elements as a JSON array from front to back
+func (q *Queue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToSlice())
+}
+
+// UnmarshalJSON replaces the elements of the queue with the ones in a JSON array.
+// A queue allocated by the decoder cannot look elements up by value, like one created by NewAny
+func (q *Queue[T]) UnmarshalJSON(data []byte) error {
+	var elems []T
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	return q.load(elems)
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGob(t *testing.T) {
+	type state struct {
+		Name    string
+		Pending *Queue[int]
+	}
+
+	in := state{Name: "jobs", Pending: New[int]()}
+	for i := 1; i <= 4; i++ {
+		in.Pending.Append(i)
+	}
+	in.Pending.Remove(2)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatalf("Encode should succeed, got %v", err)
+	}
+	var out state
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("Decode should succeed, got %v", err)
+	}
+	if s := out.Pending.ToSlice(); out.Name != "jobs" || !reflect.DeepEqual(s, []int{1, 3, 4}) {
+		t.Errorf("Decoded queue should be [1 3 4], it is %v", s)
+	}
+	out.Pending.Append(5)
+	if p := out.Pending.Pop(); p != 1 {
+		t.Errorf("There should be 1 on pop, there is %d", p)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	q := New[string]()
+	q.Append("b")
+	q.Prepend("a")
+
+	data, err := json.Marshal(map[string]*Queue[string]{"pending": q})
+	if err != nil || string(data) != `{"pending":["a","b"]}` {
+		t.Errorf("Queue should marshal to a FIFO array, got %s (%v)", data, err)
+	}
+
+	var out struct {
+		Pending *Queue[string] `json:"pending"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal should succeed, got %v", err)
+	}
+	if s := out.Pending.ToSlice(); !reflect.DeepEqual(s, []string{"a", "b"}) {
+		t.Errorf("Unmarshalled queue should be [a b], it is %v", s)
+	}
+
+	if data, _ := json.Marshal(New[int]()); string(data) != "[]" {
+		t.Errorf("Empty queue should marshal to [], got %s", data)
+	}
+}
@@ -0,0 +1,112 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Envelope is an element together with what the queue knows about it
+type Envelope[T any] struct {
+	Elem T
+	// Handle of the element, pass it to Ack or Nack if it was reserved
+	Handle Handle
+	// Enqueued is when the element was added, it is zero unless the queue was
+	// created with WithEnvelopes or the element was added by AppendWithHeaders
+	Enqueued time.Time
+	// Attempts counts the deliveries of the element, including this one
+	Attempts int
+	// Headers are the headers passed to AppendWithHeaders
+	Headers map[string]string
+}
+
+// entry is an element taken out of the queue together with its id and metadata
+type entry[T any] struct {
+	id   int64
+	elem T
+	meta metadata
+}
+
+// metadata is what the queue tracks about an element besides its value
+type metadata struct {
+	enqueued time.Time
+	// how many times Reserve has handed the element out
+	deliveries int
+	headers    map[string]string
+}
+
+// WithEnvelopes makes the queue record when every element was added,
+// so that PopEnvelope and ReserveEnvelope can report it
+func WithEnvelopes[T any]() Option[T] {
+	return func(s *settings[T]) {
+		s.envelopes = true
+	}
+}
+
+// setMeta records meta for the element stored under id
+func (q *Queue[T]) setMeta(id int64, meta metadata) {
+	if q.meta == nil {
+		q.meta = make(map[int64]metadata)
+	}
+	q.meta[id] = meta
+}
+
+// AppendWithHeaders works like Append and attaches headers to elem,
+// they are returned with it by PopEnvelope and ReserveEnvelope
+func (q *Queue[T]) AppendWithHeaders(elem T, headers map[string]string) Handle {
+	h, _ := q.appendWithHeaders(context.Background(), elem, headers)
+	return h
+}
+
+// appendWithHeaders works like AppendWithHeaders, but fails like AppendContext
+func (q *Queue[T]) appendWithHeaders(ctx context.Context, elem T, headers map[string]string) (Handle, error) {
+	elem, err := q.intercept(OpAppend, elem)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if err := q.admitAppend(ctx, elem, true); err != nil {
+		return 0, err
+	}
+	id := q.append(elem)
+	q.setMeta(id, metadata{enqueued: q.clock.Now(), headers: headers})
+	return Handle(id), nil
+}
+
+// PopEnvelope works like Pop, but returns the element in an Envelope.
+// Once the queue is closed and empty it returns the zero Envelope
+func (q *Queue[T]) PopEnvelope() Envelope[T] {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	e, err := q.takeEntry(context.Background(), q.popFront)
+	if err != nil {
+		return Envelope[T]{}
+	}
+	e.meta.deliveries++
+	return e.envelope()
+}
+
+// ReserveEnvelope works like Reserve, but returns the element in an Envelope
+func (q *Queue[T]) ReserveEnvelope() (Envelope[T], error) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	e, err := q.reserve(context.Background())
+	if err != nil {
+		return Envelope[T]{}, err
+	}
+	return e.envelope(), nil
+}
+
+func (e entry[T]) envelope() Envelope[T] {
+	return Envelope[T]{
+		Elem:     e.elem,
+		Handle:   Handle(e.id),
+		Enqueued: e.meta.enqueued,
+		Attempts: e.meta.deliveries,
+		Headers:  e.meta.headers,
+	}
+}
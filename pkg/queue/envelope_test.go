@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPopEnvelope(t *testing.T) {
+	clock := newFakeClock()
+	q := New[string](WithEnvelopes[string](), WithClock[string](clock))
+
+	q.Append("plain")
+	clock.Advance(time.Minute)
+	h := q.AppendWithHeaders("tagged", map[string]string{"trace": "abc"})
+
+	e := q.PopEnvelope()
+	if e.Elem != "plain" || !e.Enqueued.Equal(time.Unix(0, 0)) || e.Attempts != 1 || e.Headers != nil {
+		t.Errorf("Unexpected envelope for plain: %+v", e)
+	}
+	e = q.PopEnvelope()
+	if e.Elem != "tagged" || e.Handle != h || e.Headers["trace"] != "abc" || !e.Enqueued.Equal(time.Unix(60, 0)) {
+		t.Errorf("Unexpected envelope for tagged: %+v", e)
+	}
+}
+
+func TestReserveEnvelopeAttempts(t *testing.T) {
+	q := New[int]()
+	q.AppendWithHeaders(1, map[string]string{"k": "v"})
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		e, err := q.ReserveEnvelope()
+		if err != nil || e.Attempts != attempt || e.Headers["k"] != "v" {
+			t.Errorf("Attempt %d: unexpected envelope %+v (%v)", attempt, e, err)
+		}
+		q.Nack(e.Handle)
+	}
+}
@@ -0,0 +1,46 @@
+package queue
+
+// EvictReason tells the eviction callback why an element was dropped
+type EvictReason int
+
+const (
+	// EvictCapacity means a full bounded queue dropped the element to make room,
+	// see OverflowDropOldest and OverflowDropNewest
+	EvictCapacity EvictReason = iota
+	// EvictClean means the element was removed by Clean
+	EvictClean
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictClean:
+		return "clean"
+	}
+	return "unknown"
+}
+
+type eviction[T any] struct {
+	elem   T
+	reason EvictReason
+}
+
+// unlock releases the mutex and then reports the evictions, appends, pops and
+// log events that happened while it was held, so the callbacks may safely use the queue
+func (q *Queue[T]) unlock() {
+	e := q.pending()
+	q.mutex.Unlock()
+	if e != nil {
+		q.report(e)
+	}
+}
+
+// pending takes the events recorded while the mutex was held, to be reported
+// once the mutex is released. It returns nil if there are none
+func (q *Queue[T]) pending() *events[T] {
+	if len(q.evicted) == 0 && len(q.appended) == 0 && len(q.popped) == 0 && len(q.logs) == 0 && len(q.crossings) == 0 {
+		return nil
+	}
+	return q.takeEvents()
+}
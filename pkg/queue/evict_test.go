@@ -0,0 +1,71 @@
+package queue
+
+import "testing"
+
+func TestOnEvictCapacity(t *testing.T) {
+	var evicted []int
+	var q *Queue[int]
+	q = NewBoundedWithPolicy(2, OverflowDropOldest, WithOnEvict(func(elem int, reason EvictReason) {
+		if reason != EvictCapacity {
+			t.Errorf("reason should be capacity, it is %v", reason)
+		}
+		// the queue is unlocked while the callback runs
+		q.Length()
+		evicted = append(evicted, elem)
+	}))
+
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+	q.TryAppend(5)
+
+	expected := []int{0, 1, 2, 3}
+	if len(evicted) != len(expected) {
+		t.Fatalf("evicted should be %v, it is %v", expected, evicted)
+	}
+	for i := range expected {
+		if evicted[i] != expected[i] {
+			t.Errorf("evicted should be %v, it is %v", expected, evicted)
+		}
+	}
+}
+
+func TestOnEvictClean(t *testing.T) {
+	reasons := map[EvictReason]int{}
+	q := New(WithOnEvict(func(elem string, reason EvictReason) {
+		reasons[reason]++
+	}))
+
+	q.Append("a")
+	q.Append("b")
+	q.Pop()
+	q.Append("c")
+	q.Clean()
+
+	if reasons[EvictClean] != 2 || len(reasons) != 1 {
+		t.Errorf("Clean should evict 2 elements, evicted %v", reasons)
+	}
+	if EvictClean.String() != "clean" {
+		t.Errorf("EvictClean should be named clean, it is %s", EvictClean)
+	}
+}
+
+func TestOnEvictMerge(t *testing.T) {
+	evicted := 0
+	q := NewBoundedWithPolicy(1, OverflowDropNewest, WithOnEvict(func(elem int, reason EvictReason) {
+		evicted++
+	}))
+	other := New[int]()
+
+	q.Append(1)
+	other.Append(2)
+	other.Append(3)
+	q.MergeFrom(other)
+
+	if evicted != 2 {
+		t.Errorf("MergeFrom should evict 2 elements, it evicted %d", evicted)
+	}
+	if q.Pop() != 3 {
+		t.Error("the last merged element should be kept")
+	}
+}
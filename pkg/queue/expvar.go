@@ -0,0 +1,22 @@
+package queue
+
+// WithExpvar publishes statistics of the queue under name with the expvar package:
+// its current length, the number of elements appended and popped so far and the
+// peak length it has reached. Like expvar.Publish it panics if name is already in use,
+// so every queue needs a name of its own
+func WithExpvar[T any](name string) Option[T] {
+	return func(s *settings[T]) {
+		s.expvar = name
+	}
+}
+
+// expvarStats returns the statistics published by WithExpvar
+func (q *Queue[T]) expvarStats() any {
+	s := q.Stats()
+	return map[string]uint64{
+		"length":   uint64(s.Length),
+		"appended": s.Appends,
+		"popped":   s.Pops,
+		"peak":     uint64(s.Peak),
+	}
+}
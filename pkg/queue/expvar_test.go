@@ -0,0 +1,30 @@
+package queue
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"testing"
+)
+
+func TestWithExpvar(t *testing.T) {
+	q := New[int](WithExpvar[int]("queue_test_expvar"))
+	q.Append(1)
+	q.Append(2)
+	q.Append(3)
+	q.Pop()
+	q.Remove(3)
+
+	v := expvar.Get("queue_test_expvar")
+	if v == nil {
+		t.Fatal("WithExpvar should publish the statistics")
+	}
+	var got map[string]uint64
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]uint64{"length": 1, "appended": 3, "popped": 1, "peak": 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
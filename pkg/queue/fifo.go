@@ -0,0 +1,38 @@
+package queue
+
+// fifo is a plain slice backed FIFO for the queues that keep their elements in
+// several lists, such as the lanes of a KeyedQueue. It is not safe for concurrent use
+type fifo[E any] struct {
+	elems []E
+	// position of the front element in elems
+	head int
+}
+
+func (f *fifo[E]) size() int {
+	return len(f.elems) - f.head
+}
+
+func (f *fifo[E]) front() E {
+	return f.elems[f.head]
+}
+
+func (f *fifo[E]) pushBack(e E) {
+	f.elems = append(f.elems, e)
+}
+
+func (f *fifo[E]) popFront() E {
+	var zero E
+	e := f.elems[f.head]
+	f.elems[f.head] = zero
+	f.head++
+	// move the elements down once the popped ones take up half of the slice
+	if f.head*2 >= len(f.elems) {
+		n := copy(f.elems, f.elems[f.head:])
+		for i := n; i < len(f.elems); i++ {
+			f.elems[i] = zero
+		}
+		f.elems = f.elems[:n]
+		f.head = 0
+	}
+	return e
+}
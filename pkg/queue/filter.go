@@ -0,0 +1,109 @@
+package queue
+
+// walk calls f for every queued element from front to back until f returns false.
+// The mutex must be held
+func (q *Queue[T]) walk(f func(pos int, elem T) bool) {
+	for i := 0; i < q.count; i++ {
+		pos := (q.head + i) & (len(q.buf) - 1)
+		if !f(pos, q.buf[pos].elem) {
+			return
+		}
+	}
+}
+
+// RemoveFunc removes every element for which pred returns true and returns
+// how many were removed. pred is called from front to back while the queue is locked
+func (q *Queue[T]) RemoveFunc(pred func(T) bool) int {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	removed := q.sweep(pred)
+	if removed > 0 {
+		q.removes += uint64(removed)
+		q.removed()
+	}
+	return removed
+}
+
+// ToSlice returns a copy of the queued elements from front to back
+func (q *Queue[T]) ToSlice() []T {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	result := make([]T, 0, q.size)
+	q.walk(func(_ int, elem T) bool {
+		result = append(result, elem)
+		return true
+	})
+	return result
+}
+
+// Range calls f for every element from front to back and stops when f returns false.
+// The queue is locked while Range runs, so f must not call methods of the queue
+func (q *Queue[T]) Range(f func(T) bool) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	q.walk(func(_ int, elem T) bool {
+		return f(elem)
+	})
+}
+
+// Filter keeps only the elements for which keep returns true, preserving their order.
+// keep is called from front to back while the queue is locked
+func (q *Queue[T]) Filter(keep func(T) bool) {
+	q.RemoveFunc(func(elem T) bool {
+		return !keep(elem)
+	})
+}
+
+// Find returns the element closest to the front for which pred returns true,
+// without removing it. It returns false if no element matches.
+// pred is called from front to back while the queue is locked
+func (q *Queue[T]) Find(pred func(T) bool) (T, bool) {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	var found T
+	ok := false
+	q.walk(func(_ int, elem T) bool {
+		if pred(elem) {
+			found, ok = elem, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
+
+// FindAll returns the elements for which pred returns true from front to back,
+// without removing them. pred is called while the queue is locked
+func (q *Queue[T]) FindAll(pred func(T) bool) []T {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	var result []T
+	q.walk(func(_ int, elem T) bool {
+		if pred(elem) {
+			result = append(result, elem)
+		}
+		return true
+	})
+	return result
+}
+
+// CountFunc returns how many elements pred returns true for.
+// pred is called from front to back while the queue is locked
+func (q *Queue[T]) CountFunc(pred func(T) bool) int {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	n := 0
+	q.walk(func(_ int, elem T) bool {
+		if pred(elem) {
+			n++
+		}
+		return true
+	})
+	return n
+}
@@ -0,0 +1,144 @@
+package queue
+
+import "testing"
+
+func TestRemoveFunc(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+
+	removed := q.RemoveFunc(func(i int) bool { return i%3 == 0 })
+	if removed != 4 {
+		t.Errorf("RemoveFunc should remove 4 elements, it removed %d", removed)
+	}
+	if q.Length() != 6 {
+		t.Errorf("Queue length should be 6, it is %d", q.Length())
+	}
+	for _, expected := range []int{1, 2, 4, 5, 7, 8} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestRemoveFuncNonComparable(t *testing.T) {
+	q := NewAny[[]string]()
+
+	q.Append([]string{"stale"})
+	q.Append([]string{"fresh"})
+	q.Append([]string{"stale"})
+
+	if removed := q.RemoveFunc(func(s []string) bool { return s[0] == "stale" }); removed != 2 {
+		t.Errorf("RemoveFunc should remove 2 elements, it removed %d", removed)
+	}
+	if p := q.Pop(); p[0] != "fresh" {
+		t.Errorf("There should be fresh on pop, there is %v", p)
+	}
+}
+
+func TestToSlice(t *testing.T) {
+	q := New[int]()
+
+	if s := q.ToSlice(); len(s) != 0 {
+		t.Errorf("ToSlice of an empty queue should be empty, it is %v", s)
+	}
+
+	q.Append(2)
+	q.Append(3)
+	q.Append(4)
+	q.Prepend(1)
+	q.Remove(3)
+
+	expected := []int{1, 2, 4}
+	s := q.ToSlice()
+	if len(s) != len(expected) {
+		t.Fatalf("ToSlice should return %v, it returned %v", expected, s)
+	}
+	for i := range expected {
+		if s[i] != expected[i] {
+			t.Errorf("ToSlice should return %v, it returned %v", expected, s)
+		}
+	}
+	if q.Length() != 3 {
+		t.Errorf("ToSlice should not change the queue, length is %d", q.Length())
+	}
+}
+
+func TestRange(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	q.Remove(1)
+
+	var visited []int
+	q.Range(func(i int) bool {
+		visited = append(visited, i)
+		return i < 4
+	})
+
+	expected := []int{0, 2, 3, 4}
+	if len(visited) != len(expected) {
+		t.Fatalf("Range should visit %v, it visited %v", expected, visited)
+	}
+	for i := range expected {
+		if visited[i] != expected[i] {
+			t.Errorf("Range should visit %v, it visited %v", expected, visited)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	q.Filter(func(i int) bool { return i%2 == 1 })
+
+	if q.Length() != 5 {
+		t.Errorf("Queue length should be 5, it is %d", q.Length())
+	}
+	for _, expected := range []int{1, 3, 5, 7, 9} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	q := New[int]()
+	for i := 1; i <= 5; i++ {
+		q.Append(i)
+	}
+
+	if elem, ok := q.Find(func(elem int) bool { return elem > 2 }); !ok || elem != 3 {
+		t.Errorf("Find should return 3, it returned %d", elem)
+	}
+	if _, ok := q.Find(func(elem int) bool { return elem > 5 }); ok {
+		t.Error("Find should return false if nothing matches")
+	}
+	if all := q.FindAll(func(elem int) bool { return elem%2 == 1 }); len(all) != 3 || all[0] != 1 || all[2] != 5 {
+		t.Errorf("FindAll should return 1 3 5, it returned %v", all)
+	}
+	if q.Length() != 5 {
+		t.Errorf("Find should not remove elements, length is %d", q.Length())
+	}
+}
+
+func TestCountFunc(t *testing.T) {
+	q := New[string]()
+	for _, s := range []string{"a:1", "b:1", "a:2"} {
+		q.Append(s)
+	}
+
+	if n := q.CountFunc(func(s string) bool { return s[0] == 'a' }); n != 2 {
+		t.Errorf("There should be 2 elements of a, there are %d", n)
+	}
+	if n := q.CountFunc(func(s string) bool { return s[0] == 'c' }); n != 0 {
+		t.Errorf("There should be no elements of c, there are %d", n)
+	}
+}
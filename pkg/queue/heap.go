@@ -0,0 +1,72 @@
+package queue
+
+import "context"
+
+// HeapQueue is a blocking queue that always pops its smallest (or largest) element
+// according to a comparator. Append and Pop cost O(log n), equal elements are
+// popped in the order they were appended
+type HeapQueue[T any] struct {
+	q *PriorityQueue[T]
+	// You can subscribe to this channel to know whether queue is not empty
+	NotEmpty chan struct{}
+}
+
+// NewMinHeap creates a HeapQueue that pops the smallest element first.
+// cmp returns a negative number when elem1 is smaller than elem2, zero when
+// they are equal and a positive number otherwise
+func NewMinHeap[T any](cmp func(elem1 T, elem2 T) int) *HeapQueue[T] {
+	return newHeap(func(a, b *priorityEntry[T]) int {
+		return cmp(a.elem, b.elem)
+	})
+}
+
+// NewMaxHeap creates a HeapQueue that pops the largest element first, see NewMinHeap
+func NewMaxHeap[T any](cmp func(elem1 T, elem2 T) int) *HeapQueue[T] {
+	return newHeap(func(a, b *priorityEntry[T]) int {
+		return cmp(b.elem, a.elem)
+	})
+}
+
+func newHeap[T any](cmp func(a, b *priorityEntry[T]) int) *HeapQueue[T] {
+	q := newPriority(cmp)
+	return &HeapQueue[T]{q: q, NotEmpty: q.NotEmpty}
+}
+
+// Adds one element to the heap, appending to a closed queue is a no-op
+func (h *HeapQueue[T]) Append(elem T) {
+	h.q.Push(elem, 0)
+}
+
+// Returns the number of elements in queue
+func (h *HeapQueue[T]) Length() int {
+	return h.q.Length()
+}
+
+// Previews the element that Pop would return
+func (h *HeapQueue[T]) Front() T {
+	return h.q.Front()
+}
+
+// Pop removes and returns the smallest (or largest) element.
+// If the queue is empty, it will block. Once the queue is closed and
+// empty it returns the zero value
+func (h *HeapQueue[T]) Pop() T {
+	return h.q.Pop()
+}
+
+// Take works like Pop, but returns ErrClosed instead of the zero value
+// once the queue is closed and empty
+func (h *HeapQueue[T]) Take() (T, error) {
+	return h.q.Take()
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (h *HeapQueue[T]) PopContext(ctx context.Context) (T, error) {
+	return h.q.PopContext(ctx)
+}
+
+// Close wakes up every goroutine blocked in Pop and stops the queue from
+// accepting new elements, see Queue.Close
+func (h *HeapQueue[T]) Close() error {
+	return h.q.Close()
+}
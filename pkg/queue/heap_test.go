@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMinHeap(t *testing.T) {
+	q := NewMinHeap(func(a int, b int) int { return a - b })
+
+	for i := 0; i < 10000; i++ {
+		q.Append(rand.Intn(100000))
+	}
+	if q.Length() != 10000 {
+		t.Errorf("Queue length should be 10000, it is %d", q.Length())
+	}
+
+	prev := q.Pop()
+	for q.Length() > 0 {
+		if q.Front() < prev {
+			t.Errorf("Front should not be smaller than the last pop %d", prev)
+		}
+		cur := q.Pop()
+		if cur < prev {
+			t.Errorf("heap order is wrong %d < %d", cur, prev)
+		}
+		prev = cur
+	}
+}
+
+func TestMaxHeap(t *testing.T) {
+	type job struct {
+		cost, seq int
+	}
+	q := NewMaxHeap(func(a job, b job) int { return a.cost - b.cost })
+
+	for i := 0; i < 1000; i++ {
+		q.Append(job{rand.Intn(10), i})
+	}
+
+	prev := q.Pop()
+	for q.Length() > 0 {
+		cur := q.Pop()
+		if cur.cost > prev.cost {
+			t.Errorf("heap order is wrong %d > %d", cur.cost, prev.cost)
+		}
+		if cur.cost == prev.cost && cur.seq < prev.seq {
+			t.Errorf("equal elements should pop in FIFO order, %d came after %d", cur.seq, prev.seq)
+		}
+		prev = cur
+	}
+
+	q.Close()
+	if _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed, got %v", err)
+	}
+}
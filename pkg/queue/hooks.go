@@ -0,0 +1,31 @@
+package queue
+
+// WithOnAppend registers a hook that is called with every element added to the
+// queue by Append, Prepend, InsertAt and the like. It runs after the queue has
+// been unlocked, in the goroutine that added the element
+func WithOnAppend[T any](onAppend func(elem T)) Option[T] {
+	return func(s *settings[T]) {
+		s.onAppend = onAppend
+	}
+}
+
+// WithOnPop registers a hook that is called with every element taken from the
+// queue by Pop, PopBack, Take, Reserve and the like, but not for elements that are
+// removed or evicted. It runs after the queue has been unlocked, in the goroutine
+// that popped the element
+func WithOnPop[T any](onPop func(elem T)) Option[T] {
+	return func(s *settings[T]) {
+		s.onPop = onPop
+	}
+}
+
+// added counts elem and records it for the append hook, the mutex must be held
+func (q *Queue[T]) added(elem T) {
+	q.appends++
+	if q.size > q.peak {
+		q.peak = q.size
+	}
+	if q.onAppend != nil {
+		q.appended = append(q.appended, elem)
+	}
+}
@@ -0,0 +1,41 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var appended, popped []int
+	var lengths []int
+	var q *Queue[int]
+	q = New[int](
+		WithOnAppend(func(elem int) {
+			appended = append(appended, elem)
+			// hooks run unlocked, so they may use the queue
+			lengths = append(lengths, q.Length())
+		}),
+		WithOnPop(func(elem int) {
+			popped = append(popped, elem)
+		}),
+	)
+
+	q.Append(1)
+	q.Prepend(0)
+	q.InsertAt(1, 5)
+	q.Pop()
+	q.Take()
+	q.Reserve()
+	q.Append(2)
+	q.Remove(2)
+
+	if !reflect.DeepEqual(appended, []int{1, 0, 5, 2}) {
+		t.Errorf("OnAppend should see [1 0 5 2], it saw %v", appended)
+	}
+	if !reflect.DeepEqual(lengths, []int{1, 2, 3, 1}) {
+		t.Errorf("OnAppend should run after each append, lengths were %v", lengths)
+	}
+	if !reflect.DeepEqual(popped, []int{0, 5, 1}) {
+		t.Errorf("OnPop should see [0 5 1], it saw %v", popped)
+	}
+}
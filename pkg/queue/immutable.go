@@ -0,0 +1,64 @@
+package queue
+
+// ImmutableQueue is a read-only copy of the contents of a queue, see Queue.Freeze.
+// It can be read from any number of goroutines without locking
+type ImmutableQueue[T any] struct {
+	// view shares the buffer and the elements of the queue it was frozen from,
+	// it is never locked or changed
+	view *Queue[T]
+}
+
+// Freeze returns the current contents of the queue as an ImmutableQueue in O(1).
+// The queue and the copy share their memory until the queue is next changed,
+// which then copies it once, so iterating over the copy never holds up producers
+// and consumers the way Range does
+func (q *Queue[T]) Freeze() ImmutableQueue[T] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.frozen = true
+	return ImmutableQueue[T]{view: &Queue[T]{
+		length: int64(q.size),
+		buf:    q.buf,
+		head:   q.head,
+		count:  q.count,
+	}}
+}
+
+// thaw gives the queue its own copy of the memory it shares with an ImmutableQueue,
+// it must be called before the elements or the buffer are changed
+func (q *Queue[T]) thaw() {
+	if !q.frozen {
+		return
+	}
+	q.frozen = false
+	q.buf = append([]slot[T](nil), q.buf...)
+}
+
+// Length returns the number of elements
+func (iq ImmutableQueue[T]) Length() int {
+	if iq.view == nil {
+		return 0
+	}
+	return int(iq.view.length)
+}
+
+// Range calls f for every element from front to back until f returns false
+func (iq ImmutableQueue[T]) Range(f func(T) bool) {
+	if iq.view == nil {
+		return
+	}
+	iq.view.walk(func(_ int, elem T) bool {
+		return f(elem)
+	})
+}
+
+// ToSlice returns the elements from front to back
+func (iq ImmutableQueue[T]) ToSlice() []T {
+	result := make([]T, 0, iq.Length())
+	iq.Range(func(elem T) bool {
+		result = append(result, elem)
+		return true
+	})
+	return result
+}
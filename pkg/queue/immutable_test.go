@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+	q.Remove(2)
+	frozen := q.Freeze()
+
+	q.Pop()
+	q.Append(5)
+	q.Prepend(6)
+	q.MoveToBack(3)
+	q.QuickSort(func(a, b int) int { return b - a })
+
+	if got := frozen.ToSlice(); !reflect.DeepEqual(got, []int{0, 1, 3, 4}) {
+		t.Errorf("the frozen copy should not change, it holds %v", got)
+	}
+	if frozen.Length() != 4 {
+		t.Errorf("expected 4 frozen elements, got %d", frozen.Length())
+	}
+	if got := q.ToSlice(); !reflect.DeepEqual(got, []int{6, 5, 4, 3, 1}) {
+		t.Errorf("expected the queue to hold [6 5 4 3 1], it holds %v", got)
+	}
+
+	again := q.Freeze()
+	q.Clean()
+	if again.Length() != 5 || q.Length() != 0 {
+		t.Errorf("Clean should empty the queue but not its frozen copy, lengths are %d and %d", q.Length(), again.Length())
+	}
+
+	var empty ImmutableQueue[int]
+	if empty.Length() != 0 || len(empty.ToSlice()) != 0 {
+		t.Error("the zero ImmutableQueue should be empty")
+	}
+}
+
+func TestFreezeConcurrent(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 1000; i++ {
+		q.Append(i)
+	}
+	frozen := q.Freeze()
+	done := make(chan int)
+	go func() {
+		sum := 0
+		frozen.Range(func(elem int) bool {
+			sum += elem
+			return true
+		})
+		done <- sum
+	}()
+	for i := 0; i < 1000; i++ {
+		q.Pop()
+		q.Append(i)
+	}
+	if sum := <-done; sum != 999*1000/2 {
+		t.Errorf("expected the frozen elements to sum to %d, got %d", 999*1000/2, sum)
+	}
+}
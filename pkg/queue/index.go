@@ -0,0 +1,165 @@
+package queue
+
+import "sync/atomic"
+
+// slot is a place in the ring buffer, the count slots from head hold the queued
+// elements and the others have id 0
+type slot[T any] struct {
+	id   int64
+	elem T
+}
+
+// index counts the queued elements by value, so that deduplication can tell
+// whether an element is queued without scanning the buffer.
+// It is only available for comparable element types
+type index[T any] interface {
+	add(elem T)
+	remove(elem T)
+	contains(elem T) bool
+	reset()
+}
+
+// valueIndex counts the occurrences of every queued element
+type valueIndex[T comparable] map[T]int
+
+func (v valueIndex[T]) add(elem T) {
+	v[elem]++
+}
+
+func (v valueIndex[T]) remove(elem T) {
+	if v[elem] <= 1 {
+		delete(v, elem)
+		return
+	}
+	v[elem]--
+}
+
+func (v valueIndex[T]) contains(elem T) bool {
+	return v[elem] > 0
+}
+
+func (v valueIndex[T]) reset() {
+	for elem := range v {
+		delete(v, elem)
+	}
+}
+
+func equal[T comparable](elem1, elem2 T) bool {
+	return elem1 == elem2
+}
+
+// store records that elem is queued under id, the caller puts its slot in the
+// buffer. The mutex must be held
+func (q *Queue[T]) store(id int64, elem T) {
+	q.size++
+	atomic.StoreInt64(&q.length, int64(q.size))
+	if q.sizer != nil {
+		q.bytes += q.sizer(elem)
+	}
+	if q.values != nil {
+		q.values.add(elem)
+	}
+	if q.envelopes {
+		q.setMeta(id, metadata{enqueued: q.clock.Now()})
+	}
+}
+
+// forget records that elem queued under id is gone, the caller takes its slot
+// out of the buffer. The mutex must be held
+func (q *Queue[T]) forget(id int64, elem T) {
+	q.size--
+	atomic.StoreInt64(&q.length, int64(q.size))
+	if q.sizer != nil {
+		q.bytes -= q.sizer(elem)
+	}
+	delete(q.meta, id)
+	if q.values != nil {
+		q.values.remove(elem)
+	}
+	if q.coalesce != nil {
+		q.unremember(id, elem)
+	}
+}
+
+// kill removes the element at buffer position pos
+func (q *Queue[T]) kill(pos int) {
+	s := q.cut(pos)
+	q.forget(s.id, s.elem)
+}
+
+// cut takes the slot at buffer position pos out of the buffer and returns it.
+// The slots on the side closer to the end of the buffer move up to close the gap
+func (q *Queue[T]) cut(pos int) slot[T] {
+	q.thaw()
+	mask := len(q.buf) - 1
+	s := q.buf[pos]
+	if (pos-q.head)&mask < q.count/2 {
+		for j := pos; j != q.head; j = (j - 1) & mask {
+			q.buf[j] = q.buf[(j-1)&mask]
+		}
+		q.buf[q.head] = slot[T]{}
+		q.head = (q.head + 1) & mask
+	} else {
+		last := (q.tail - 1) & mask
+		for j := pos; j != last; j = (j + 1) & mask {
+			q.buf[j] = q.buf[(j+1)&mask]
+		}
+		q.buf[last] = slot[T]{}
+		q.tail = last
+	}
+	q.count--
+	q.shrink()
+	return s
+}
+
+// sweep removes the elements for which remove returns true and closes the gaps
+// they leave in a single pass. remove is called from front to back, the number
+// of removed elements is returned
+func (q *Queue[T]) sweep(remove func(elem T) bool) int {
+	q.thaw()
+	mask := len(q.buf) - 1
+	kept := 0
+	for i := 0; i < q.count; i++ {
+		s := q.buf[(q.head+i)&mask]
+		if remove(s.elem) {
+			q.forget(s.id, s.elem)
+			continue
+		}
+		q.buf[(q.head+kept)&mask] = s
+		kept++
+	}
+	for i := kept; i < q.count; i++ {
+		q.buf[(q.head+i)&mask] = slot[T]{}
+	}
+	removed := q.count - kept
+	q.count = kept
+	q.tail = (q.head + kept) & mask
+	return removed
+}
+
+// newEmpty creates an empty unbounded queue that can hold the same elements as q
+func (q *Queue[T]) newEmpty() *Queue[T] {
+	return newQueue[T](q.equal, nil)
+}
+
+// lookup returns the buffer position of the first occurrence of elem,
+// it panics if the queue cannot compare elements
+func (q *Queue[T]) lookup(elem T) (int, bool) {
+	equal := q.matcher()
+	found, ok := 0, false
+	q.walk(func(pos int, queued T) bool {
+		if equal(queued, elem) {
+			found, ok = pos, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
+
+func (q *Queue[T]) matcher() func(elem1, elem2 T) bool {
+	if q.equal == nil {
+		panic("queue: elements can only be looked up by value in a queue of comparable elements, use handles instead")
+	}
+	return q.equal
+}
@@ -0,0 +1,59 @@
+package queue
+
+// Op identifies the operation an Interceptor is called for
+type Op int
+
+const (
+	// OpAppend is Append, AppendContext, TryAppend and AppendWithHeaders
+	OpAppend Op = iota
+	// OpPrepend is Prepend and PrependAll
+	OpPrepend
+	// OpInsert is InsertAt
+	OpInsert
+	// OpSet is Set
+	OpSet
+	// OpUpsert is Upsert and UpsertFunc
+	OpUpsert
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpAppend:
+		return "append"
+	case OpPrepend:
+		return "prepend"
+	case OpInsert:
+		return "insert"
+	case OpSet:
+		return "set"
+	case OpUpsert:
+		return "upsert"
+	}
+	return "unknown"
+}
+
+// Interceptor is called with every element before op adds it to the queue. It
+// returns the element to continue with, which may be changed, or an error to veto
+// the operation. Methods that return an error return that one, the others
+// report failure the way they do when the queue is closed
+type Interceptor[T any] func(op Op, elem T) (T, error)
+
+// WithInterceptor adds interceptors to the queue, they are called in the order
+// they were given. They run before the queue is locked and may use it. Elements
+// moved by MergeFrom or put back by Nack are not intercepted
+func WithInterceptor[T any](interceptors ...Interceptor[T]) Option[T] {
+	return func(s *settings[T]) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// intercept passes elem through the interceptors
+func (q *Queue[T]) intercept(op Op, elem T) (T, error) {
+	for _, interceptor := range q.interceptors {
+		var err error
+		if elem, err = interceptor(op, elem); err != nil {
+			return elem, err
+		}
+	}
+	return elem, nil
+}
@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInterceptor(t *testing.T) {
+	errNegative := errors.New("negative")
+	var ops []Op
+	q := New[int](
+		WithInterceptor(func(op Op, elem int) (int, error) {
+			ops = append(ops, op)
+			if elem < 0 {
+				return elem, errNegative
+			}
+			return elem, nil
+		}),
+		WithInterceptor(func(op Op, elem int) (int, error) {
+			return elem * 10, nil
+		}),
+	)
+
+	if err := q.AppendContext(context.Background(), -1); err != errNegative {
+		t.Errorf("a vetoed AppendContext should return the error, got %v", err)
+	}
+	if h := q.Append(-2); h != 0 {
+		t.Errorf("a vetoed Append should return the zero Handle, got %v", h)
+	}
+	if q.TryAppend(-3) {
+		t.Error("a vetoed TryAppend should return false")
+	}
+
+	q.Append(1)
+	q.Prepend(2)
+	q.InsertAt(1, 3)
+	q.Set(0, 4)
+	q.PrependAll(5, -6)
+
+	if got := q.Drain(); !reflect.DeepEqual(got, []int{50, 40, 30, 10}) {
+		t.Errorf("interceptors should transform elements in order, queue holds %v", got)
+	}
+	want := []Op{OpAppend, OpAppend, OpAppend, OpAppend, OpPrepend, OpInsert, OpSet, OpPrepend, OpPrepend}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("interceptor should see ops %v, saw %v", want, ops)
+	}
+}
@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"context"
+	"io"
+)
+
+// Appender is the producing side of a queue
+type Appender[T any] interface {
+	// AppendContext adds elem to the queue. A queue that has to wait for room
+	// gives up with ctx.Err() once ctx is done
+	AppendContext(ctx context.Context, elem T) error
+}
+
+// Popper is the consuming side of a queue
+type Popper[T any] interface {
+	// Take removes and returns the next element, blocking while there is none.
+	// It returns ErrClosed once the queue is closed and empty
+	Take() (T, error)
+	// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+	PopContext(ctx context.Context) (T, error)
+}
+
+// Interface is implemented by every FIFO queue in this package, in memory or
+// persistent, so code can accept any of them and tests can pass a fake
+type Interface[T any] interface {
+	Appender[T]
+	Popper[T]
+	// Length returns the number of queued elements
+	Length() int
+	// Close stops the queue from accepting elements and wakes up blocked calls,
+	// a persistent queue also releases its files or connections
+	Close() error
+}
+
+var (
+	_ Interface[int]    = (*Queue[int])(nil)
+	_ Interface[int]    = (*JournalQueue[int])(nil)
+	_ Interface[int]    = (*SpillQueue[int])(nil)
+	_ Interface[int]    = (*SQLQueue[int])(nil)
+	_ Interface[int]    = (*ShardedQueue[int])(nil)
+	_ Interface[[]byte] = (*MmapRing)(nil)
+	_ Popper[int]       = (*PriorityQueue[int])(nil)
+	_ Popper[int]       = (*HeapQueue[int])(nil)
+	_ Popper[int]       = (*DelayQueue[int])(nil)
+
+	// the queues that do not fit Interface close the same way
+	_ io.Closer = (*PriorityQueue[int])(nil)
+	_ io.Closer = (*HeapQueue[int])(nil)
+	_ io.Closer = (*DelayQueue[int])(nil)
+	_ io.Closer = (*KeyedQueue[string, int])(nil)
+	_ io.Closer = (*PriorityLevels[int])(nil)
+	_ io.Closer = (*Scheduler[int])(nil)
+	_ io.Closer = (*Tee[int])(nil)
+)
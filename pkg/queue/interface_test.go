@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeQueue is the kind of test double Interface makes possible
+type fakeQueue[T any] struct {
+	elems []T
+}
+
+func (f *fakeQueue[T]) AppendContext(_ context.Context, elem T) error {
+	f.elems = append(f.elems, elem)
+	return nil
+}
+
+func (f *fakeQueue[T]) Take() (T, error) {
+	return f.PopContext(context.Background())
+}
+
+func (f *fakeQueue[T]) PopContext(_ context.Context) (T, error) {
+	var zero T
+	if len(f.elems) == 0 {
+		return zero, ErrClosed
+	}
+	elem := f.elems[0]
+	f.elems = f.elems[1:]
+	return elem, nil
+}
+
+func (f *fakeQueue[T]) Length() int {
+	return len(f.elems)
+}
+
+func (f *fakeQueue[T]) Close() error {
+	return nil
+}
+
+// relay moves everything from src to dst, it accepts any queue
+func relay[T any](src Popper[T], dst Appender[T]) int {
+	moved := 0
+	for {
+		elem, err := src.Take()
+		if err != nil {
+			return moved
+		}
+		dst.AppendContext(context.Background(), elem)
+		moved++
+	}
+}
+
+func TestInterface(t *testing.T) {
+	spill, err := NewSpill[int](t.TempDir(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spill.Close()
+
+	queues := []Interface[int]{New[int](), NewBounded[int](5), spill, &fakeQueue[int]{}}
+	for _, q := range queues {
+		for i := 0; i < 3; i++ {
+			if err := q.AppendContext(context.Background(), i); err != nil {
+				t.Errorf("%T: AppendContext should succeed, got %v", q, err)
+			}
+		}
+		if q.Length() != 3 {
+			t.Errorf("%T: Queue length should be 3, it is %d", q, q.Length())
+		}
+		if item, err := q.Take(); err != nil || item != 0 {
+			t.Errorf("%T: There should be 0 on take, there is %v (%v)", q, item, err)
+		}
+	}
+
+	fake := &fakeQueue[int]{elems: []int{1, 2}}
+	if moved := relay[int](fake, queues[0]); moved != 2 || queues[0].Length() != 4 {
+		t.Errorf("relay should move 2 elements, it moved %d", moved)
+	}
+
+	for _, q := range queues[:2] {
+		if err := q.Close(); err != nil {
+			t.Errorf("%T: Close should succeed, got %v", q, err)
+		}
+		if err := q.AppendContext(context.Background(), 1); err != ErrClosed {
+			t.Errorf("%T: AppendContext after Close should return ErrClosed, got %v", q, err)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrCorrupt is returned when a journal contains a damaged record that RepairMode cannot fix
+var ErrCorrupt = errors.New("queue: journal is corrupt")
+
+// RepairMode tells OpenJournal what to do with a journal whose last record was
+// only partly written, as happens when the process dies while appending
+type RepairMode int
+
+const (
+	// RepairNone refuses to open a damaged journal with ErrCorrupt
+	RepairNone RepairMode = iota
+	// RepairTruncate cuts a torn final record off the journal, the operation it
+	// recorded is lost as it never completed. Damage elsewhere is still ErrCorrupt
+	RepairTruncate
+)
+
+const (
+	journalAppend byte = 'a'
+	journalPop    byte = 'p'
+	// crc32 and length of the payload
+	journalHeaderSize = 8
+	// DefaultSegmentSize is the size at which a journal starts a new segment file
+	// unless it was opened with WithSegmentSize
+	DefaultSegmentSize = 64 << 20
+)
+
+// JournalQueue is a FIFO queue whose appends and pops are recorded in a
+// write-ahead journal. Opening the journal again replays it, so after an
+// unclean shutdown the queue holds exactly the elements that were not popped.
+// The journal is split into segment files, segments whose elements have all
+// been popped are deleted in the background
+type JournalQueue[T any] struct {
+	q           *Queue[T]
+	dir         string
+	codec       Codec[T]
+	segmentSize int64
+	// segments from oldest to newest, records are written to the newest one
+	segments []journalSegment
+	file     segmentFile
+	size     int64
+	// sequence numbers of the element at the front and of the next element appended
+	head, next uint64
+	compact    chan struct{}
+	done       chan struct{}
+}
+
+// segmentFile is the newest segment as the journal writes it, an *os.File
+type segmentFile interface {
+	io.WriteCloser
+	Truncate(size int64) error
+	Sync() error
+}
+
+type journalSegment struct {
+	// sequence number of the first element appended to the segment
+	first uint64
+	path  string
+}
+
+// WithRepairMode sets how OpenJournal deals with a torn final record, RepairNone by default
+func WithRepairMode[T any](mode RepairMode) Option[T] {
+	return func(s *settings[T]) {
+		s.repair = mode
+	}
+}
+
+// WithSegmentSize sets the size in bytes at which a journal starts a new segment file
+func WithSegmentSize[T any](size int64) Option[T] {
+	return func(s *settings[T]) {
+		s.segmentSize = size
+	}
+}
+
+// OpenJournal opens the journal in directory dir, creating it if it does not
+// exist, and replays it. Elements are encoded with the codec set by WithCodec
+func OpenJournal[T any](dir string, opts ...Option[T]) (*JournalQueue[T], error) {
+	s := newSettings(opts)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	j := &JournalQueue[T]{
+		q:           NewAny[T](),
+		dir:         dir,
+		codec:       s.codec,
+		segmentSize: s.segmentSize,
+		compact:     make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	if err := j.replay(s.repair); err != nil {
+		return nil, err
+	}
+	go j.compactor()
+	j.compact <- struct{}{}
+	return j, nil
+}
+
+// replay applies the records of every segment and opens the newest one for writing
+func (j *JournalQueue[T]) replay(mode RepairMode) error {
+	paths, err := filepath.Glob(filepath.Join(j.dir, "*.seg"))
+	if err != nil {
+		return err
+	}
+	for i, path := range paths {
+		first, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), ".seg"), 10, 64)
+		if err != nil {
+			return ErrCorrupt
+		}
+		last := i == len(paths)-1
+		if j.size, err = j.replaySegment(path, last && mode == RepairTruncate); err != nil {
+			return err
+		}
+		j.segments = append(j.segments, journalSegment{first: first, path: path})
+	}
+
+	if len(j.segments) == 0 {
+		return j.rotate()
+	}
+	j.file, err = os.OpenFile(j.segments[len(j.segments)-1].path, os.O_WRONLY|os.O_APPEND, 0o644)
+	return err
+}
+
+// replaySegment applies the records in the segment at path and returns its size.
+// With repair a torn final record is cut off
+func (j *JournalQueue[T]) replaySegment(path string, repair bool) (int64, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	size := info.Size()
+	r := bufio.NewReader(file)
+	var offset int64
+	for offset < size {
+		payload, err := readJournalRecord(r, size-offset)
+		end := offset + int64(journalHeaderSize+len(payload))
+		if err == io.ErrUnexpectedEOF || err == ErrCorrupt {
+			// only the final record can be torn, damage in the middle is not repaired
+			if !repair || (err == ErrCorrupt && end != size) {
+				return 0, ErrCorrupt
+			}
+			return offset, file.Truncate(offset)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if err := j.apply(payload); err != nil {
+			return 0, err
+		}
+		offset = end
+	}
+	return offset, nil
+}
+
+// apply replays one record. Records carry the sequence number of their element,
+// so pops stay correct after the segments holding earlier records were deleted
+func (j *JournalQueue[T]) apply(payload []byte) error {
+	if len(payload) < 9 {
+		return ErrCorrupt
+	}
+	seq := binary.LittleEndian.Uint64(payload[1:9])
+	switch payload[0] {
+	case journalAppend:
+		elem, err := j.codec.Decode(payload[9:])
+		if err != nil {
+			return err
+		}
+		if j.q.size == 0 {
+			j.head = seq
+		}
+		j.q.append(elem)
+		j.next = seq + 1
+	case journalPop:
+		for j.head <= seq && j.q.size > 0 {
+			j.q.takeEntry(context.Background(), j.q.popFront)
+			j.head++
+		}
+		if j.head <= seq {
+			j.head = seq + 1
+		}
+	default:
+		return ErrCorrupt
+	}
+	return nil
+}
+
+// rotate starts a new segment, named after the next element appended
+func (j *JournalQueue[T]) rotate() error {
+	path := filepath.Join(j.dir, fmt.Sprintf("%020d.seg", j.next))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file = file
+	j.size = 0
+	j.segments = append(j.segments, journalSegment{first: j.next, path: path})
+	return nil
+}
+
+// compactor deletes the segments whose elements have all been popped
+func (j *JournalQueue[T]) compactor() {
+	defer close(j.done)
+	for range j.compact {
+		j.q.mutex.Lock()
+		n := 0
+		for n < len(j.segments)-1 && j.segments[n+1].first <= j.head {
+			n++
+		}
+		consumed := append([]journalSegment(nil), j.segments[:n]...)
+		j.segments = j.segments[n:]
+		j.q.mutex.Unlock()
+
+		for _, s := range consumed {
+			os.Remove(s.path)
+		}
+	}
+}
+
+// readJournalRecord reads one record out of the remaining bytes and returns its
+// payload. A record cut short returns io.ErrUnexpectedEOF, one with a wrong
+// checksum returns its payload and ErrCorrupt
+func readJournalRecord(r io.Reader, remaining int64) ([]byte, error) {
+	var header [journalHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	n := int64(binary.LittleEndian.Uint32(header[4:]))
+	if n > remaining-journalHeaderSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if n == 0 || crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[:4]) {
+		return payload, ErrCorrupt
+	}
+	return payload, nil
+}
+
+// write appends a record for the element with sequence number seq in a single
+// write. An append starts a new segment once the newest one is full. A failed
+// write is cut off again, so that no torn record ends up between later ones
+func (j *JournalQueue[T]) write(op byte, seq uint64, data []byte) error {
+	payload := make([]byte, 9+len(data))
+	payload[0] = op
+	binary.LittleEndian.PutUint64(payload[1:], seq)
+	copy(payload[9:], data)
+
+	record := make([]byte, journalHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record, crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(record[4:], uint32(len(payload)))
+	copy(record[journalHeaderSize:], payload)
+
+	if op == journalAppend && j.size > 0 && j.size+int64(len(record)) > j.segmentSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.file.Write(record)
+	if err != nil {
+		if n > 0 {
+			j.file.Truncate(j.size)
+		}
+		return err
+	}
+	j.size += int64(n)
+	return nil
+}
+
+// Returns the number of elements in queue
+func (j *JournalQueue[T]) Length() int {
+	return j.q.Length()
+}
+
+// Append records elem in the journal and adds it at the back of the queue.
+// Appending to a closed queue returns ErrClosed
+func (j *JournalQueue[T]) Append(elem T) error {
+	data, err := j.codec.Encode(elem)
+	if err != nil {
+		return err
+	}
+
+	j.q.mutex.Lock()
+	defer j.q.unlock()
+
+	if j.q.closed {
+		return ErrClosed
+	}
+	if err := j.write(journalAppend, j.next, data); err != nil {
+		return err
+	}
+	j.next++
+	j.q.append(elem)
+	return nil
+}
+
+// AppendContext works like Append, it fails with ctx.Err() if ctx is already done
+func (j *JournalQueue[T]) AppendContext(ctx context.Context, elem T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return j.Append(elem)
+}
+
+// Take removes and returns the element at the front, blocking while the queue is empty.
+// Once the queue is closed it returns ErrClosed
+func (j *JournalQueue[T]) Take() (T, error) {
+	return j.PopContext(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (j *JournalQueue[T]) PopContext(ctx context.Context) (T, error) {
+	j.q.mutex.Lock()
+	defer j.q.unlock()
+
+	var zero T
+	if j.q.closed {
+		return zero, ErrClosed
+	}
+	// wait for an element without taking it, the pop has to be recorded first
+	if _, err := j.q.pop(ctx, func() slot[T] { return slot[T]{} }); err != nil {
+		return zero, err
+	}
+	if j.q.closed {
+		// closed while waiting, the journal cannot record the pop anymore
+		return zero, ErrClosed
+	}
+	if err := j.write(journalPop, j.head, nil); err != nil {
+		// the pop was not recorded, so it does not happen
+		return zero, err
+	}
+	e := j.q.consume(j.q.popFront())
+	j.head++
+	if len(j.segments) > 1 && j.segments[1].first <= j.head {
+		select {
+		case j.compact <- struct{}{}:
+		default:
+		}
+	}
+	return e.elem, nil
+}
+
+// Sync commits the newest segment to stable storage. Without it an operation
+// survives a crash of the process, but not necessarily one of the machine
+func (j *JournalQueue[T]) Sync() error {
+	j.q.mutex.Lock()
+	defer j.q.mutex.Unlock()
+
+	if j.file == nil {
+		return ErrClosed
+	}
+	return j.file.Sync()
+}
+
+// Close closes the journal and waits for the background deletion of consumed
+// segments, the queue cannot be used afterwards and blocked calls return ErrClosed.
+// Elements that were not popped stay in the journal
+func (j *JournalQueue[T]) Close() error {
+	j.q.Close()
+
+	j.q.mutex.Lock()
+	if j.file == nil {
+		j.q.mutex.Unlock()
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	close(j.compact)
+	j.q.mutex.Unlock()
+
+	<-j.done
+	return err
+}
@@ -0,0 +1,163 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal[string](path)
+	if err != nil {
+		t.Fatalf("OpenJournal should succeed, got %v", err)
+	}
+	for _, s := range []string{"a", "b", "c"} {
+		j.Append(s)
+	}
+	if item, err := j.Take(); err != nil || item != "a" {
+		t.Errorf("There should be a on take, there is %v (%v)", item, err)
+	}
+	j.Append("d")
+	// no Close, as if the process died
+
+	j, err = OpenJournal[string](path)
+	if err != nil {
+		t.Fatalf("Reopening should succeed, got %v", err)
+	}
+	defer j.Close()
+	if s := j.q.ToSlice(); !reflect.DeepEqual(s, []string{"b", "c", "d"}) {
+		t.Errorf("Replayed queue should be [b c d], it is %v", s)
+	}
+	j.Take()
+	if j.Length() != 2 {
+		t.Errorf("Queue length should be 2, it is %d", j.Length())
+	}
+}
+
+func TestJournalRepair(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _ := OpenJournal[int](path)
+	j.Append(1)
+	j.Append(2)
+	j.Close()
+
+	segment := filepath.Join(path, fmt.Sprintf("%020d.seg", 0))
+	info, _ := os.Stat(segment)
+	os.Truncate(segment, info.Size()-3)
+
+	if _, err := OpenJournal[int](path); err != ErrCorrupt {
+		t.Errorf("A torn journal should return ErrCorrupt, got %v", err)
+	}
+	j, err := OpenJournal[int](path, WithRepairMode[int](RepairTruncate))
+	if err != nil {
+		t.Fatalf("Repair should succeed, got %v", err)
+	}
+	if s := j.q.ToSlice(); !reflect.DeepEqual(s, []int{1}) {
+		t.Errorf("Repaired queue should be [1], it is %v", s)
+	}
+	j.Append(3)
+	j.Close()
+
+	j, err = OpenJournal[int](path)
+	if err != nil {
+		t.Fatalf("Repaired journal should open cleanly, got %v", err)
+	}
+	defer j.Close()
+	if s := j.q.ToSlice(); !reflect.DeepEqual(s, []int{1, 3}) {
+		t.Errorf("Queue should be [1 3], it is %v", s)
+	}
+}
+
+// tornFile writes only half of the next record and then fails
+type tornFile struct {
+	segmentFile
+	failed bool
+}
+
+func (f *tornFile) Write(p []byte) (int, error) {
+	if f.failed {
+		return f.segmentFile.Write(p)
+	}
+	f.failed = true
+	n, _ := f.segmentFile.Write(p[:len(p)/2])
+	return n, errors.New("disk full")
+}
+
+func TestJournalFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _ := OpenJournal[int](path)
+	j.Append(1)
+	j.file = &tornFile{segmentFile: j.file}
+	if err := j.Append(2); err == nil {
+		t.Error("Append should return the error of the failed write")
+	}
+	j.Append(3)
+	j.Close()
+
+	j, err := OpenJournal[int](path)
+	if err != nil {
+		t.Fatalf("A journal with a failed write should open cleanly, got %v", err)
+	}
+	defer j.Close()
+	if s := j.q.ToSlice(); !reflect.DeepEqual(s, []int{1, 3}) {
+		t.Errorf("Queue should be [1 3], it is %v", s)
+	}
+}
+
+func TestJournalSegments(t *testing.T) {
+	dir := t.TempDir()
+	j, err := OpenJournal[int](dir, WithSegmentSize[int](64))
+	if err != nil {
+		t.Fatalf("OpenJournal should succeed, got %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		j.Append(i)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segments) < 5 {
+		t.Fatalf("Journal should be split into segments, there are %d", len(segments))
+	}
+	for i := 0; i < 15; i++ {
+		j.Take()
+	}
+	j.Close()
+
+	remaining, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(remaining) >= len(segments) {
+		t.Errorf("Consumed segments should be deleted, %d of %d are left", len(remaining), len(segments))
+	}
+
+	j, err = OpenJournal[int](dir, WithSegmentSize[int](64))
+	if err != nil {
+		t.Fatalf("Reopening should succeed, got %v", err)
+	}
+	defer j.Close()
+	if s := j.q.ToSlice(); !reflect.DeepEqual(s, []int{15, 16, 17, 18, 19}) {
+		t.Errorf("Replayed queue should be [15 16 17 18 19], it is %v", s)
+	}
+	j.Append(20)
+	if item, _ := j.Take(); item != 15 {
+		t.Errorf("There should be 15 on take, there is %d", item)
+	}
+}
+
+func TestJournalFailedPop(t *testing.T) {
+	j, _ := OpenJournal[int](filepath.Join(t.TempDir(), "journal"))
+	defer j.Close()
+	j.Append(1)
+	j.file = &tornFile{segmentFile: j.file}
+
+	if _, err := j.Take(); err == nil {
+		t.Error("Take should return the error of the failed write")
+	}
+	if pops := j.q.Stats().Pops; pops != 0 || j.Length() != 1 {
+		t.Errorf("A pop that was not recorded should not happen, %d pops and length %d", pops, j.Length())
+	}
+	if item, err := j.Take(); err != nil || item != 1 {
+		t.Errorf("There should be 1 on take, there is %v (%v)", item, err)
+	}
+}
@@ -0,0 +1,283 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// KeyedQueue keeps a separate FIFO for every key. Elements of the same key are
+// always popped in the order they were appended, Pop takes the element that was
+// appended first over all keys and blocks while the queue is empty.
+// A queue created by NewKeyedFair pops from the keys in turn instead
+type KeyedQueue[K comparable, V any] struct {
+	lanes map[K]*lane[K, V]
+	// lanes that hold elements, in the order Pop serves them
+	ready  laneOrder[K, V]
+	seq    uint64
+	length int
+	mutex  *sync.Mutex
+	// broadcast whenever a lane becomes ready
+	notEmpty *sync.Cond
+	closed   bool
+	// popped elements a key may have unacked, 0 for no limit
+	inFlightLimit int
+}
+
+// KeyedOption configures a KeyedQueue when it is created
+type KeyedOption func(*keyedSettings)
+
+type keyedSettings struct {
+	inFlightLimit int
+}
+
+// WithInFlightLimit lets every key of a KeyedQueue have at most n popped elements
+// that have not been passed to Ack yet. Pop skips a key that reached the limit
+// until one of its elements is acked, so with n = 1 the elements of a key are
+// handled one at a time. Panics if n is not positive
+func WithInFlightLimit(n int) KeyedOption {
+	if n <= 0 {
+		panic("queue: in-flight limit must be positive")
+	}
+	return func(s *keyedSettings) {
+		s.inFlightLimit = n
+	}
+}
+
+// lane is the FIFO of one key
+type lane[K comparable, V any] struct {
+	key K
+	fifo[laneEntry[V]]
+	// popped elements that have not been acked, see WithInFlightLimit
+	inFlight int
+}
+
+type laneEntry[V any] struct {
+	elem V
+	// append sequence number over all keys
+	seq uint64
+}
+
+// laneOrder decides which lane Pop serves next
+type laneOrder[K comparable, V any] interface {
+	push(l *lane[K, V])
+	pop() *lane[K, V]
+	len() int
+}
+
+// oldestFirst serves the lane whose front element was appended first
+type oldestFirst[K comparable, V any] struct {
+	entryHeap[*lane[K, V]]
+}
+
+func (o *oldestFirst[K, V]) push(l *lane[K, V]) { heap.Push(&o.entryHeap, l) }
+func (o *oldestFirst[K, V]) pop() *lane[K, V]   { return heap.Pop(&o.entryHeap).(*lane[K, V]) }
+func (o *oldestFirst[K, V]) len() int           { return o.Len() }
+
+// roundRobin serves the lanes in turn, a lane that still has elements after
+// Pop goes to the back
+type roundRobin[K comparable, V any] struct {
+	lanes fifo[*lane[K, V]]
+}
+
+func (r *roundRobin[K, V]) push(l *lane[K, V]) { r.lanes.pushBack(l) }
+func (r *roundRobin[K, V]) pop() *lane[K, V]   { return r.lanes.popFront() }
+func (r *roundRobin[K, V]) len() int           { return r.lanes.size() }
+
+// NewKeyed creates an empty KeyedQueue that pops the element appended first
+func NewKeyed[K comparable, V any](opts ...KeyedOption) *KeyedQueue[K, V] {
+	return newKeyed[K, V](opts, &oldestFirst[K, V]{entryHeap[*lane[K, V]]{less: func(a, b **lane[K, V]) bool {
+		return (*a).front().seq < (*b).front().seq
+	}}})
+}
+
+// NewKeyedFair creates an empty KeyedQueue that pops from the keys in turn, one
+// element per key, so that a key with a large backlog cannot hold up the others.
+// The keys are served in the order they got their first element
+func NewKeyedFair[K comparable, V any](opts ...KeyedOption) *KeyedQueue[K, V] {
+	return newKeyed[K, V](opts, &roundRobin[K, V]{})
+}
+
+func newKeyed[K comparable, V any](opts []KeyedOption, ready laneOrder[K, V]) *KeyedQueue[K, V] {
+	var s keyedSettings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	q := &KeyedQueue[K, V]{
+		lanes:         make(map[K]*lane[K, V]),
+		ready:         ready,
+		mutex:         &sync.Mutex{},
+		inFlightLimit: s.inFlightLimit,
+	}
+	q.notEmpty = sync.NewCond(q.mutex)
+	return q
+}
+
+// Returns the number of elements over all keys
+func (q *KeyedQueue[K, V]) Length() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.length
+}
+
+// KeyLength returns the number of elements queued under key
+func (q *KeyedQueue[K, V]) KeyLength(key K) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if l, ok := q.lanes[key]; ok {
+		return l.size()
+	}
+	return 0
+}
+
+// Keys returns the keys that have elements queued, in no particular order
+func (q *KeyedQueue[K, V]) Keys() []K {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	keys := make([]K, 0, len(q.lanes))
+	for key, l := range q.lanes {
+		if l.size() > 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Append adds elem at the back of the FIFO of key.
+// It returns false if the queue is closed
+func (q *KeyedQueue[K, V]) Append(key K, elem V) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return false
+	}
+	l, ok := q.lanes[key]
+	if !ok {
+		l = &lane[K, V]{key: key}
+		q.lanes[key] = l
+	}
+	q.seq++
+	l.pushBack(laneEntry[V]{elem, q.seq})
+	q.length++
+	if l.size() == 1 && !q.saturated(l) {
+		q.ready.push(l)
+		q.notEmpty.Broadcast()
+	}
+	return true
+}
+
+// take pops from the next ready lane. Elements held back in lanes at the
+// in-flight limit keep a closed queue open until they are acked and popped
+func (q *KeyedQueue[K, V]) take(ctx context.Context) (K, V, error) {
+	for q.ready.len() == 0 {
+		if q.closed && q.length == 0 {
+			var key K
+			var zero V
+			return key, zero, ErrClosed
+		}
+		if err := waitContext(ctx, q.notEmpty); err != nil {
+			var key K
+			var zero V
+			return key, zero, err
+		}
+	}
+
+	l := q.ready.pop()
+	e := l.popFront()
+	q.length--
+	if q.inFlightLimit > 0 {
+		l.inFlight++
+	}
+	if l.size() > 0 && !q.saturated(l) {
+		q.ready.push(l)
+	} else if l.size() == 0 && l.inFlight == 0 {
+		delete(q.lanes, l.key)
+	}
+	return l.key, e.elem, nil
+}
+
+// saturated reports whether l has reached the in-flight limit
+func (q *KeyedQueue[K, V]) saturated(l *lane[K, V]) bool {
+	return q.inFlightLimit > 0 && l.inFlight >= q.inFlightLimit
+}
+
+// Ack reports that an element popped from key has been handled, so that Pop
+// may serve key again if it had reached the limit set by WithInFlightLimit.
+// It returns ErrNotReserved if key has no element in flight
+func (q *KeyedQueue[K, V]) Ack(key K) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	l, ok := q.lanes[key]
+	if !ok || l.inFlight == 0 {
+		return ErrNotReserved
+	}
+	l.inFlight--
+	switch {
+	case l.size() == 0 && l.inFlight == 0:
+		delete(q.lanes, key)
+	case l.size() > 0 && l.inFlight == q.inFlightLimit-1:
+		// the lane was held back at the limit
+		q.ready.push(l)
+		q.notEmpty.Broadcast()
+	}
+	return nil
+}
+
+// InFlight returns the number of elements popped from key that have not been acked
+func (q *KeyedQueue[K, V]) InFlight(key K) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if l, ok := q.lanes[key]; ok {
+		return l.inFlight
+	}
+	return 0
+}
+
+// Pop removes and returns the element that was appended first, with its key,
+// or the front element of the next key in turn on a queue created by NewKeyedFair.
+// If the queue is empty, it will block. Once the queue is closed and
+// empty it returns the zero values
+func (q *KeyedQueue[K, V]) Pop() (K, V) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	key, elem, _ := q.take(context.Background())
+	return key, elem
+}
+
+// Take works like Pop, but returns ErrClosed instead of the zero values
+// once the queue is closed and empty
+func (q *KeyedQueue[K, V]) Take() (K, V, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (q *KeyedQueue[K, V]) PopContext(ctx context.Context) (K, V, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(ctx)
+}
+
+// Close wakes up every goroutine blocked in Pop and stops the queue from
+// accepting new elements, see Queue.Close
+func (q *KeyedQueue[K, V]) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.notEmpty.Broadcast()
+	return nil
+}
@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeyedQueue(t *testing.T) {
+	q := NewKeyed[string, int]()
+	q.Append("a", 1)
+	q.Append("b", 1)
+	q.Append("a", 2)
+	q.Append("c", 1)
+	q.Append("b", 2)
+
+	if q.Length() != 5 || q.KeyLength("a") != 2 || len(q.Keys()) != 3 {
+		t.Errorf("Queue should hold 5 elements under 3 keys, it holds %d under %v", q.Length(), q.Keys())
+	}
+	for _, expected := range []struct {
+		key  string
+		elem int
+	}{{"a", 1}, {"b", 1}, {"a", 2}, {"c", 1}, {"b", 2}} {
+		if key, elem := q.Pop(); key != expected.key || elem != expected.elem {
+			t.Errorf("There should be %s %d on pop, there is %s %d", expected.key, expected.elem, key, elem)
+		}
+	}
+	if q.Length() != 0 || q.KeyLength("a") != 0 {
+		t.Errorf("Queue should be empty, it holds %d", q.Length())
+	}
+}
+
+func TestKeyedQueueLongLane(t *testing.T) {
+	q := NewKeyed[int, int]()
+	for i := 0; i < 100; i++ {
+		q.Append(i%2, i)
+	}
+	for i := 0; i < 100; i++ {
+		if key, elem := q.Pop(); key != i%2 || elem != i {
+			t.Fatalf("There should be %d on pop, there is %d", i, elem)
+		}
+	}
+}
+
+func TestKeyedQueueBlocking(t *testing.T) {
+	q := NewKeyed[string, int]()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Append("a", 1)
+	}()
+	if key, elem := q.Pop(); key != "a" || elem != 1 {
+		t.Errorf("Pop should wait for a 1, it returned %s %d", key, elem)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := q.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("PopContext should time out, got %v", err)
+	}
+
+	q.Close()
+	if q.Append("a", 2) {
+		t.Error("Append to a closed queue should return false")
+	}
+	if _, _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take on a closed queue should return ErrClosed, got %v", err)
+	}
+}
+
+func TestKeyedQueueFair(t *testing.T) {
+	q := NewKeyedFair[string, int]()
+	for i := 1; i <= 3; i++ {
+		q.Append("hot", i)
+	}
+	q.Append("a", 1)
+	q.Append("b", 1)
+	q.Append("a", 2)
+
+	for _, expected := range []struct {
+		key  string
+		elem int
+	}{{"hot", 1}, {"a", 1}, {"b", 1}, {"hot", 2}, {"a", 2}, {"hot", 3}} {
+		if key, elem := q.Pop(); key != expected.key || elem != expected.elem {
+			t.Errorf("There should be %s %d on pop, there is %s %d", expected.key, expected.elem, key, elem)
+		}
+	}
+
+	// a key that runs empty goes to the back once it gets new elements
+	q.Append("a", 3)
+	q.Append("b", 2)
+	q.Append("a", 4)
+	if key, _ := q.Pop(); key != "a" {
+		t.Errorf("There should be a on pop, there is %s", key)
+	}
+	if key, _ := q.Pop(); key != "b" {
+		t.Errorf("There should be b on pop, there is %s", key)
+	}
+}
+
+func TestKeyedQueueInFlightLimit(t *testing.T) {
+	q := NewKeyed[string, int](WithInFlightLimit(1))
+	q.Append("a", 1)
+	q.Append("a", 2)
+	q.Append("b", 1)
+
+	if key, elem := q.Pop(); key != "a" || elem != 1 {
+		t.Errorf("There should be a 1 on pop, there is %s %d", key, elem)
+	}
+	if key, elem := q.Pop(); key != "b" || elem != 1 {
+		t.Errorf("a is in flight, there should be b 1 on pop, there is %s %d", key, elem)
+	}
+	if q.InFlight("a") != 1 || q.Length() != 1 {
+		t.Errorf("a should have 1 element in flight and 1 queued, it has %d and %d", q.InFlight("a"), q.Length())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := q.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Pop should wait for a to be acked, got %v", err)
+	}
+
+	if err := q.Ack("a"); err != nil {
+		t.Errorf("Ack should succeed, got %v", err)
+	}
+	if key, elem := q.Pop(); key != "a" || elem != 2 {
+		t.Errorf("There should be a 2 on pop, there is %s %d", key, elem)
+	}
+	q.Ack("a")
+	q.Ack("b")
+	if err := q.Ack("a"); err != ErrNotReserved {
+		t.Errorf("Ack without elements in flight should return ErrNotReserved, got %v", err)
+	}
+
+	assertPanics(t, "WithInFlightLimit", func() {
+		WithInFlightLimit(0)
+	})
+}
+
+func TestKeyedQueueAckWakesPop(t *testing.T) {
+	q := NewKeyedFair[string, int](WithInFlightLimit(1))
+	q.Append("a", 1)
+	q.Append("a", 2)
+	q.Pop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Ack("a")
+	}()
+	if _, elem := q.Pop(); elem != 2 {
+		t.Errorf("Pop should wait for the ack and return 2, it returned %d", elem)
+	}
+}
+
+func TestKeyedQueueCloseWithHeldLane(t *testing.T) {
+	q := NewKeyedFair[string, int](WithInFlightLimit(1))
+	q.Append("a", 1)
+	q.Append("a", 2)
+	q.Pop()
+	q.Close()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Ack("a")
+	}()
+	if _, elem, err := q.Take(); err != nil || elem != 2 {
+		t.Errorf("Take should wait for the ack and return 2, it returned %d (%v)", elem, err)
+	}
+	if _, _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take on the closed and empty queue should return ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package queue
+
+import "time"
+
+// HistogramBuckets is the number of buckets of a Histogram
+const HistogramBuckets = 32
+
+// Histogram counts durations in exponential buckets: bucket i holds the durations
+// below HistogramBound(i) that did not fit in a lower bucket, the last bucket
+// holds everything longer
+type Histogram struct {
+	Count   uint64
+	Sum     time.Duration
+	Buckets [HistogramBuckets]uint64
+}
+
+// HistogramBound returns the upper bound of bucket i of a Histogram,
+// one microsecond doubled i times
+func HistogramBound(i int) time.Duration {
+	return time.Microsecond << i
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := 0
+	for i < HistogramBuckets-1 && d >= HistogramBound(i) {
+		i++
+	}
+	h.Buckets[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// Mean returns the average duration, 0 for an empty histogram
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Percentile returns the upper bound of the bucket that holds the p-th percentile,
+// with p between 0 and 100, so the result overestimates by at most a factor of two.
+// It returns 0 for an empty histogram
+func (h Histogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(p / 100 * float64(h.Count))
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+	var seen uint64
+	for i, n := range h.Buckets {
+		seen += n
+		if seen > rank {
+			return HistogramBound(i)
+		}
+	}
+	return HistogramBound(HistogramBuckets - 1)
+}
+
+// WithLatencyHistogram makes the queue measure how long every element was queued
+// before it was popped or reserved, Stats reports the durations in Latency.
+// Like WithEnvelopes it records when every element was added
+func WithLatencyHistogram[T any]() Option[T] {
+	return func(s *settings[T]) {
+		s.envelopes = true
+		s.latency = true
+	}
+}
+
+// observeLatency adds the time the element described by meta spent in the queue to
+// the latency histogram, the mutex must be held
+func (q *Queue[T]) observeLatency(meta metadata) {
+	if q.latency && !meta.enqueued.IsZero() {
+		q.latencies.observe(q.clock.Now().Sub(meta.enqueued))
+	}
+}
@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	var h Histogram
+	if h.Percentile(50) != 0 || h.Mean() != 0 {
+		t.Error("an empty histogram should report 0")
+	}
+	for i := 0; i < 9; i++ {
+		h.observe(3 * time.Microsecond)
+	}
+	h.observe(time.Second)
+
+	if h.Buckets[2] != 9 {
+		t.Errorf("3µs belongs in bucket 2, buckets are %v", h.Buckets)
+	}
+	if p := h.Percentile(50); p != 4*time.Microsecond {
+		t.Errorf("expected a median of 4µs, got %v", p)
+	}
+	if p := h.Percentile(100); p != HistogramBound(20) {
+		t.Errorf("expected a maximum of %v, got %v", HistogramBound(20), p)
+	}
+	h.observe(time.Hour)
+	if h.Buckets[HistogramBuckets-1] != 1 {
+		t.Errorf("an hour should go in the last bucket, buckets are %v", h.Buckets)
+	}
+}
+
+func TestWithLatencyHistogram(t *testing.T) {
+	clock := newFakeClock()
+	q := New[int](WithLatencyHistogram[int](), WithClock[int](clock))
+	q.Append(1)
+	q.Append(2)
+	clock.Advance(10 * time.Millisecond)
+	q.Pop()
+	clock.Advance(10 * time.Millisecond)
+	q.Reserve()
+
+	latency := q.Stats().Latency
+	if latency.Count != 2 || latency.Mean() != 15*time.Millisecond {
+		t.Errorf("expected 2 elements queued for 15ms on average, got %d for %v", latency.Count, latency.Mean())
+	}
+	if p := latency.Percentile(99); p != HistogramBound(15) {
+		t.Errorf("expected a 99th percentile of %v, got %v", HistogramBound(15), p)
+	}
+}
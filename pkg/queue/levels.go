@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// PriorityLevels is a blocking queue with a fixed number of priority levels, each
+// a FIFO. Level 0 is the highest. Append and Pop take constant time, which makes
+// it cheaper than PriorityQueue when a few levels such as high, normal and low suffice.
+// By default Pop serves the highest level that has elements, so lower levels wait
+// for as long as higher ones are busy. A queue created by NewWeightedLevels gives
+// every level a share of the pops instead
+type PriorityLevels[T any] struct {
+	levels []fifo[T]
+	// pops every level gets per round, nil to always serve the highest level
+	weights []int
+	// pops left for every level in the current round
+	credits []int
+	length  int
+	mutex   *sync.Mutex
+	// broadcast whenever the queue stops being empty
+	notEmpty *sync.Cond
+	closed   bool
+}
+
+// NewPriorityLevels creates a queue with the given number of levels, Pop always
+// serves the highest level that has elements. Panics if levels is not positive
+func NewPriorityLevels[T any](levels int) *PriorityLevels[T] {
+	if levels <= 0 {
+		panic("queue: number of levels must be positive")
+	}
+	q := &PriorityLevels[T]{levels: make([]fifo[T], levels), mutex: &sync.Mutex{}}
+	q.notEmpty = sync.NewCond(q.mutex)
+	return q
+}
+
+// NewWeightedLevels creates a queue with a level per weight. Pop serves the levels
+// in rounds in which level i gets up to weights[i] pops, higher levels first, so
+// while every level is busy they are served in proportion to their weights and
+// no level starves. A level without elements passes its turn on to the others.
+// Panics if there are no weights or one is not positive
+func NewWeightedLevels[T any](weights ...int) *PriorityLevels[T] {
+	q := NewPriorityLevels[T](len(weights))
+	for _, w := range weights {
+		if w <= 0 {
+			panic("queue: weights must be positive")
+		}
+	}
+	q.weights = append([]int(nil), weights...)
+	q.credits = append([]int(nil), weights...)
+	return q
+}
+
+// Returns the number of elements over all levels
+func (q *PriorityLevels[T]) Length() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.length
+}
+
+// LevelLength returns the number of elements at level, 0 for a level that does not exist
+func (q *PriorityLevels[T]) LevelLength(level int) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if level < 0 || level >= len(q.levels) {
+		return 0
+	}
+	return q.levels[level].size()
+}
+
+// Append adds elem at the back of level. It returns false if the queue is closed.
+// Panics if level does not exist
+func (q *PriorityLevels[T]) Append(level int, elem T) bool {
+	if level < 0 || level >= len(q.levels) {
+		panic("queue: priority level out of range")
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return false
+	}
+	q.levels[level].pushBack(elem)
+	q.length++
+	if q.length == 1 {
+		q.notEmpty.Broadcast()
+	}
+	return true
+}
+
+// next returns the level Pop serves, the queue must not be empty
+func (q *PriorityLevels[T]) next() int {
+	if q.weights == nil {
+		for i := range q.levels {
+			if q.levels[i].size() > 0 {
+				return i
+			}
+		}
+	}
+	for {
+		for i := range q.levels {
+			if q.credits[i] > 0 && q.levels[i].size() > 0 {
+				q.credits[i]--
+				return i
+			}
+		}
+		// every level with elements used up its pops, start a new round
+		copy(q.credits, q.weights)
+	}
+}
+
+func (q *PriorityLevels[T]) take(ctx context.Context) (T, error) {
+	for q.length == 0 {
+		if q.closed {
+			var zero T
+			return zero, ErrClosed
+		}
+		if err := waitContext(ctx, q.notEmpty); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+
+	q.length--
+	return q.levels[q.next()].popFront(), nil
+}
+
+// Pop removes and returns the next element, see PriorityLevels for the order.
+// If the queue is empty, it will block. Once the queue is closed and
+// empty it returns the zero value
+func (q *PriorityLevels[T]) Pop() T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	item, _ := q.take(context.Background())
+	return item
+}
+
+// Take works like Pop, but returns ErrClosed instead of the zero value
+// once the queue is closed and empty
+func (q *PriorityLevels[T]) Take() (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (q *PriorityLevels[T]) PopContext(ctx context.Context) (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(ctx)
+}
+
+// Close wakes up every goroutine blocked in Pop and stops the queue from
+// accepting new elements, see Queue.Close
+func (q *PriorityLevels[T]) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.notEmpty.Broadcast()
+	return nil
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityLevels(t *testing.T) {
+	q := NewPriorityLevels[string](3)
+	q.Append(2, "low")
+	q.Append(1, "normal 1")
+	q.Append(0, "high")
+	q.Append(1, "normal 2")
+
+	if q.Length() != 4 || q.LevelLength(1) != 2 || q.LevelLength(5) != 0 {
+		t.Errorf("Queue should hold 4 elements, 2 at level 1, it holds %d", q.Length())
+	}
+	for _, expected := range []string{"high", "normal 1", "normal 2", "low"} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p)
+		}
+	}
+
+	assertPanics(t, "Append", func() {
+		q.Append(3, "none")
+	})
+	assertPanics(t, "NewPriorityLevels", func() {
+		NewPriorityLevels[int](0)
+	})
+}
+
+func TestWeightedLevels(t *testing.T) {
+	q := NewWeightedLevels[int](3, 1)
+	for i := 0; i < 6; i++ {
+		q.Append(0, 0)
+	}
+	q.Append(1, 1)
+	q.Append(1, 1)
+
+	var popped []int
+	for i := 0; i < 8; i++ {
+		popped = append(popped, q.Pop())
+	}
+	for i, expected := range []int{0, 0, 0, 1, 0, 0, 0, 1} {
+		if popped[i] != expected {
+			t.Fatalf("Levels should be served 3 to 1, they were served %v", popped)
+		}
+	}
+
+	// an idle level passes its turn on
+	q.Append(1, 1)
+	q.Append(1, 1)
+	if q.Pop() != 1 || q.Pop() != 1 {
+		t.Error("Level 1 should be served while level 0 is empty")
+	}
+
+	assertPanics(t, "NewWeightedLevels", func() {
+		NewWeightedLevels[int](1, 0)
+	})
+}
+
+func TestPriorityLevelsBlocking(t *testing.T) {
+	q := NewPriorityLevels[int](2)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Append(1, 1)
+	}()
+	if p := q.Pop(); p != 1 {
+		t.Errorf("Pop should wait for 1, it returned %d", p)
+	}
+
+	q.Close()
+	if q.Append(0, 2) {
+		t.Error("Append to a closed queue should return false")
+	}
+	if _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take on a closed queue should return ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package queue
+
+import "time"
+
+// DefaultLongWait is how long Pop or Append has to block before WithLogger
+// logs it, unless the queue was created with WithLongWait
+const DefaultLongWait = time.Second
+
+// Logger receives the notable events of a queue, *slog.Logger implements it.
+// args are alternating keys and values
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// LogLevel is the severity of a logged event
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+type logEntry struct {
+	level LogLevel
+	msg   string
+	args  []any
+}
+
+// WithLogger logs the events of the queue at level or above to logger: buffer
+// resizes at LogDebug, evictions and Close at LogInfo and waits in Pop or Append
+// that block longer than the long wait threshold at LogWarn. Like the hooks,
+// logger is called after the queue has been unlocked
+func WithLogger[T any](logger Logger, level LogLevel) Option[T] {
+	return func(s *settings[T]) {
+		s.logger = logger
+		s.logLevel = level
+	}
+}
+
+// WithLongWait sets how long Pop or Append has to block before WithLogger logs it
+func WithLongWait[T any](d time.Duration) Option[T] {
+	return func(s *settings[T]) {
+		s.longWait = d
+	}
+}
+
+// log records an event to be logged once the mutex is released. Its arguments
+// are allocated even when nothing is logged, so calls on the Append and Pop
+// path check logging first
+func (q *Queue[T]) log(level LogLevel, msg string, args ...any) {
+	if !q.logging(level) {
+		return
+	}
+	q.logs = append(q.logs, logEntry{level, msg, args})
+}
+
+// logging reports whether events at level are logged
+func (q *Queue[T]) logging(level LogLevel) bool {
+	return q.logger != nil && level >= q.logLevel
+}
+
+// waitStart returns when a wait starts, or the zero time if long waits are not logged
+func (q *Queue[T]) waitStart() time.Time {
+	if !q.logging(LogWarn) {
+		return time.Time{}
+	}
+	return q.clock.Now()
+}
+
+// waited logs the wait that started at start if it took too long
+func (q *Queue[T]) waited(start time.Time, msg string) {
+	if start.IsZero() {
+		return
+	}
+	if d := q.clock.Now().Sub(start); d >= q.longWait {
+		q.log(LogWarn, msg, "waited", d)
+	}
+}
+
+func (e logEntry) write(logger Logger) {
+	switch e.level {
+	case LogDebug:
+		logger.Debug(e.msg, e.args...)
+	case LogInfo:
+		logger.Info(e.msg, e.args...)
+	case LogWarn:
+		logger.Warn(e.msg, e.args...)
+	default:
+		logger.Error(e.msg, e.args...)
+	}
+}
@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) record(level, msg string, args []any) {
+	l.lines = append(l.lines, fmt.Sprint(level, " ", msg, args))
+}
+
+func (l *recordLogger) Debug(msg string, args ...any) { l.record("DEBUG", msg, args) }
+func (l *recordLogger) Info(msg string, args ...any)  { l.record("INFO", msg, args) }
+func (l *recordLogger) Warn(msg string, args ...any)  { l.record("WARN", msg, args) }
+func (l *recordLogger) Error(msg string, args ...any) { l.record("ERROR", msg, args) }
+
+// steppingClock moves forward by step every time it is read
+type steppingClock struct {
+	now, step time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.now += c.step
+	return time.Unix(0, int64(c.now))
+}
+
+func (c *steppingClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func TestWithLogger(t *testing.T) {
+	logger := &recordLogger{}
+	q := NewBoundedWithPolicy[int](minQueueLen+1, OverflowDropOldest,
+		WithLogger[int](logger, LogDebug),
+		WithClock[int](&steppingClock{step: 2 * time.Second}),
+	)
+	for i := 0; i < minQueueLen+2; i++ {
+		q.Append(i)
+	}
+	q.Clean()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	q.PopContext(ctx)
+	q.Close()
+
+	want := []string{
+		"DEBUG queue: resized buffer[from 32 to 256]",
+		"INFO queue: evicted element[reason capacity]",
+		"INFO queue: cleared[reason clean count 33]",
+		"WARN queue: waited long for an element[waited 2s]",
+		"INFO queue: closed[length 0]",
+	}
+	if !reflect.DeepEqual(logger.lines, want) {
+		t.Errorf("expected log\n%q\ngot\n%q", want, logger.lines)
+	}
+
+	logger.lines = nil
+	q = New[int](WithLogger[int](logger, LogWarn))
+	for i := 0; i < minQueueLen+1; i++ {
+		q.Append(i)
+	}
+	q.Close()
+	if len(logger.lines) != 0 {
+		t.Errorf("events below the level should not be logged, got %q", logger.lines)
+	}
+}
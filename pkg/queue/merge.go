@@ -0,0 +1,134 @@
+package queue
+
+import "context"
+
+// lockPair locks q and other in the order they were created, so that two
+// goroutines locking the same pair of queues can never deadlock.
+// The returned function unlocks both and reports the events of both, such as
+// evictions, hooks, logs and watermark crossings
+func (q *Queue[T]) lockPair(other *Queue[T]) func() {
+	first, second := q, other
+	if other.order < q.order {
+		first, second = other, q
+	}
+	first.mutex.Lock()
+	second.mutex.Lock()
+	return func() {
+		e, o := q.pending(), other.pending()
+		second.mutex.Unlock()
+		first.mutex.Unlock()
+		if e != nil {
+			q.report(e)
+		}
+		if o != nil {
+			other.report(o)
+		}
+	}
+}
+
+// MergeFrom moves every element of other to the back of q, preserving their
+// order, and returns how many were moved. Both queues are locked for the
+// duration, in the order they were created, so a.MergeFrom(b) and b.MergeFrom(a)
+// can safely run concurrently. MergeFrom never waits for room: elements that
+// do not fit in a full bounded q stay in other, as do elements that q rejects
+// as duplicates
+func (q *Queue[T]) MergeFrom(other *Queue[T]) int {
+	if other == q {
+		return 0
+	}
+	defer q.lockPair(other)()
+
+	full := false
+	moved := other.sweep(func(elem T) bool {
+		if full {
+			return false
+		}
+		if err := q.admitAppend(context.Background(), elem, false); err == ErrDuplicate {
+			return false
+		} else if err != nil {
+			full = true
+			return false
+		}
+		q.append(elem)
+		return true
+	})
+
+	if other.size == 0 {
+		other.reset()
+	}
+	if moved > 0 {
+		other.removes += uint64(moved)
+		other.removed()
+	}
+	return moved
+}
+
+// SplitAt removes the first n elements from the front of q and returns them,
+// in order, as a new unbounded queue. If q holds fewer than n elements all of
+// them are moved
+func (q *Queue[T]) SplitAt(n int) *Queue[T] {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	split := q.newEmpty()
+	moved := 0
+	for moved < n && q.count > 0 {
+		s := q.popFront()
+		q.forget(s.id, s.elem)
+		split.append(s.elem)
+		moved++
+	}
+	if moved > 0 {
+		q.removes += uint64(moved)
+		q.removed()
+	}
+	return split
+}
+
+// Partition moves every element of q into one of two new unbounded queues:
+// the elements pred returns true for and the others, each in their original
+// order. q is left empty. pred is called from front to back while q is locked
+func (q *Queue[T]) Partition(pred func(T) bool) (matched *Queue[T], rest *Queue[T]) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	matched, rest = q.newEmpty(), q.newEmpty()
+	moved := q.sweep(func(elem T) bool {
+		if pred(elem) {
+			matched.append(elem)
+		} else {
+			rest.append(elem)
+		}
+		return true
+	})
+	if moved > 0 {
+		q.removes += uint64(moved)
+		q.removed()
+	}
+	return matched, rest
+}
+
+// GroupBy moves every element of q into a new unbounded queue per key, keeping
+// the order of the elements that share a key. q is left empty. key is called
+// from front to back while q is locked
+func GroupBy[T any, K comparable](q *Queue[T], key func(T) K) map[K]*Queue[T] {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	groups := make(map[K]*Queue[T])
+	moved := q.sweep(func(elem T) bool {
+		k := key(elem)
+		group, ok := groups[k]
+		if !ok {
+			group = q.newEmpty()
+			groups[k] = group
+		}
+		group.append(elem)
+		return true
+	})
+	if moved > 0 {
+		q.removes += uint64(moved)
+		q.removed()
+	}
+	return groups
+}
@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMergeFrom(t *testing.T) {
+	q := New[int]()
+	other := New[int]()
+
+	q.Append(1)
+	q.Append(2)
+	for i := 3; i <= 50; i++ {
+		other.Append(i)
+	}
+	other.Remove(10)
+
+	if moved := q.MergeFrom(other); moved != 47 {
+		t.Errorf("MergeFrom should move 47 elements, it moved %d", moved)
+	}
+	if other.Length() != 0 {
+		t.Errorf("other should be empty, its length is %d", other.Length())
+	}
+	if q.MergeFrom(q) != 0 {
+		t.Error("merging a queue into itself should do nothing")
+	}
+	for expected := 1; expected <= 50; expected++ {
+		if expected == 10 {
+			continue
+		}
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestMergeFromReportsOther(t *testing.T) {
+	low := make(chan int, 1)
+	other := New[int](WithWatermarks[int](2, 0, nil, func(length int) {
+		low <- length
+	}))
+	for i := 0; i < 3; i++ {
+		other.Append(i)
+	}
+
+	q := New[int]()
+	q.MergeFrom(other)
+	select {
+	case length := <-low:
+		if length != 0 {
+			t.Errorf("The low watermark of other should be crossed at 0, it was at %d", length)
+		}
+	default:
+		t.Error("MergeFrom should report the low watermark crossing of other")
+	}
+	if removes := other.Stats().Removes; removes != 3 {
+		t.Errorf("other should count the 3 moved elements as removed, it counts %d", removes)
+	}
+}
+
+func TestMergeFromBounded(t *testing.T) {
+	q := NewBounded[int](3)
+	other := New[int]()
+
+	q.Append(1)
+	other.Append(2)
+	other.Append(3)
+	other.Append(4)
+
+	if moved := q.MergeFrom(other); moved != 2 {
+		t.Errorf("MergeFrom should move 2 elements, it moved %d", moved)
+	}
+	if p := other.Pop(); p != 4 {
+		t.Errorf("There should be 4 left in other, there is %v", p)
+	}
+}
+
+func TestMergeFromConcurrent(t *testing.T) {
+	a := New[int]()
+	b := New[int]()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			a.Append(i)
+			a.MergeFrom(b)
+		}
+		wg.Done()
+	}()
+	go func() {
+		for i := 0; i < 1000; i++ {
+			b.Append(i)
+			b.MergeFrom(a)
+		}
+		wg.Done()
+	}()
+	wg.Wait()
+
+	if total := a.Length() + b.Length(); total != 2000 {
+		t.Errorf("merging should not lose elements, there are %d", total)
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	q.Remove(2)
+
+	split := q.SplitAt(4)
+	if split.Length() != 4 || q.Length() != 5 {
+		t.Errorf("lengths should be 4 and 5, they are %d and %d", split.Length(), q.Length())
+	}
+	for _, expected := range []int{0, 1, 3, 4} {
+		if p := split.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+	if q.Front() != 5 {
+		t.Errorf("There should be 5 on front, there is %v", q.Front())
+	}
+	if q.Remove(3) {
+		t.Error("split elements should be removed from the index")
+	}
+
+	rest := q.SplitAt(100)
+	if rest.Length() != 5 || q.Length() != 0 {
+		t.Errorf("lengths should be 5 and 0, they are %d and %d", rest.Length(), q.Length())
+	}
+	if !rest.Remove(9) {
+		t.Error("the split queue should support removal by value")
+	}
+}
+
+func TestMoveCountsRemoves(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	q.SplitAt(4)
+	if removes := q.Stats().Removes; removes != 4 {
+		t.Errorf("SplitAt should count 4 removes, it counts %d", removes)
+	}
+	q.Partition(func(elem int) bool { return elem%2 == 0 })
+	if removes := q.Stats().Removes; removes != 10 {
+		t.Errorf("Partition should count the 6 elements it moved, there are %d removes", removes)
+	}
+	q.AppendAll(1, 2, 3)
+	GroupBy(q, func(elem int) int { return elem % 2 })
+	if removes := q.Stats().Removes; removes != 13 {
+		t.Errorf("GroupBy should count the 3 elements it moved, there are %d removes", removes)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+
+	even, odd := q.Partition(func(elem int) bool { return elem%2 == 0 })
+	if q.Length() != 0 || even.Length() != 5 || odd.Length() != 5 {
+		t.Errorf("lengths should be 0, 5 and 5, they are %d, %d and %d", q.Length(), even.Length(), odd.Length())
+	}
+	for i := 0; i < 5; i++ {
+		if p := even.Pop(); p != 2*i {
+			t.Errorf("There should be %d on pop, there is %v", 2*i, p)
+		}
+		if p := odd.Pop(); p != 2*i+1 {
+			t.Errorf("There should be %d on pop, there is %v", 2*i+1, p)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	q := New[string]()
+	for _, s := range []string{"a1", "b1", "a2", "c1", "a3"} {
+		q.Append(s)
+	}
+
+	groups := GroupBy(q, func(s string) byte { return s[0] })
+	if q.Length() != 0 || len(groups) != 3 {
+		t.Fatalf("q should be empty with 3 groups, it has %d elements and %d groups", q.Length(), len(groups))
+	}
+	if s := groups['a'].ToSlice(); len(s) != 3 || s[0] != "a1" || s[1] != "a2" || s[2] != "a3" {
+		t.Errorf("group a should hold a1 a2 a3, it holds %v", s)
+	}
+	if groups['b'].Front() != "b1" || groups['c'].Front() != "c1" {
+		t.Error("groups b and c should hold b1 and c1")
+	}
+}
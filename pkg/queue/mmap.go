@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrRecordSize is returned by MmapRing.Append for a record that does not have the ring's record size
+var ErrRecordSize = errors.New("queue: record has the wrong size")
+
+// ErrLayout is returned by OpenMmapRing for a file written with another record size or capacity
+var ErrLayout = errors.New("queue: file has a different layout")
+
+const (
+	mmapMagic      = 0x676e6972716d6d65
+	mmapHeaderSize = 64
+)
+
+// MmapRing is a bounded FIFO queue of fixed-size records kept in a memory-mapped
+// file instead of on the Go heap, so very large backlogs do not add to garbage
+// collection. The file keeps the records across restarts
+type MmapRing struct {
+	file       *os.File
+	data       []byte
+	recordSize int
+	capacity   int
+	head       int
+	count      int
+	mutex      *sync.Mutex
+	notEmpty   *sync.Cond
+	closed     bool
+}
+
+// OpenMmapRing maps the file at path, creating it for capacity records of
+// recordSize bytes if it does not exist. An existing file must have the same layout
+func OpenMmapRing(path string, recordSize, capacity int) (*MmapRing, error) {
+	if recordSize <= 0 || capacity <= 0 {
+		return nil, ErrLayout
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	size := mmapHeaderSize + recordSize*capacity
+	fresh := info.Size() == 0
+	if fresh {
+		err = file.Truncate(int64(size))
+	} else if info.Size() != int64(size) {
+		err = ErrLayout
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	data, err := mmap(file, size)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	r := &MmapRing{
+		file:       file,
+		data:       data,
+		recordSize: recordSize,
+		capacity:   capacity,
+		mutex:      &sync.Mutex{},
+	}
+	r.notEmpty = sync.NewCond(r.mutex)
+
+	if fresh {
+		r.header(0, mmapMagic)
+		r.header(1, uint64(recordSize))
+		r.header(2, uint64(capacity))
+		r.sync()
+	} else if r.field(0) != mmapMagic || r.field(1) != uint64(recordSize) || r.field(2) != uint64(capacity) {
+		r.Close()
+		return nil, ErrLayout
+	}
+	r.head = int(r.field(3))
+	r.count = int(r.field(4))
+	return r, nil
+}
+
+func (r *MmapRing) field(i int) uint64 {
+	return binary.LittleEndian.Uint64(r.data[i*8:])
+}
+
+func (r *MmapRing) header(i int, v uint64) {
+	binary.LittleEndian.PutUint64(r.data[i*8:], v)
+}
+
+// sync writes head and count to the header
+func (r *MmapRing) sync() {
+	r.header(3, uint64(r.head))
+	r.header(4, uint64(r.count))
+}
+
+func (r *MmapRing) record(i int) []byte {
+	offset := mmapHeaderSize + ((r.head+i)%r.capacity)*r.recordSize
+	return r.data[offset : offset+r.recordSize]
+}
+
+// Returns the number of records in the ring
+func (r *MmapRing) Length() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.count
+}
+
+// Returns the number of records the ring can hold
+func (r *MmapRing) Capacity() int {
+	return r.capacity
+}
+
+// Append copies record to the back of the ring. It returns ErrFull if the ring
+// is full, ErrRecordSize if record has the wrong size and ErrClosed once closed
+func (r *MmapRing) Append(record []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	switch {
+	case r.closed:
+		return ErrClosed
+	case len(record) != r.recordSize:
+		return ErrRecordSize
+	case r.count == r.capacity:
+		return ErrFull
+	}
+	copy(r.record(r.count), record)
+	r.count++
+	r.sync()
+	r.notEmpty.Broadcast()
+	return nil
+}
+
+// AppendContext works like Append, it fails with ctx.Err() if ctx is already done
+func (r *MmapRing) AppendContext(ctx context.Context, record []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Append(record)
+}
+
+// TryPop removes the front record and returns a copy of it without blocking,
+// ok is false if the ring is empty or closed
+func (r *MmapRing) TryPop() (record []byte, ok bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed || r.count == 0 {
+		return nil, false
+	}
+	return r.pop(), true
+}
+
+// Take removes the front record and returns a copy of it, blocking while the ring is empty
+func (r *MmapRing) Take() ([]byte, error) {
+	return r.PopContext(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (r *MmapRing) PopContext(ctx context.Context) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for r.count == 0 && !r.closed {
+		if err := waitContext(ctx, r.notEmpty); err != nil {
+			return nil, err
+		}
+	}
+	if r.closed {
+		return nil, ErrClosed
+	}
+	return r.pop(), nil
+}
+
+func (r *MmapRing) pop() []byte {
+	record := append([]byte(nil), r.record(0)...)
+	r.head = (r.head + 1) % r.capacity
+	r.count--
+	r.sync()
+	return record
+}
+
+// Close unmaps and closes the file, records that were not popped stay in it.
+// Blocked calls return ErrClosed
+func (r *MmapRing) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.notEmpty.Broadcast()
+	err := munmap(r.data)
+	r.data = nil
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package queue
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoMmap = errors.New("queue: memory mapping is not supported on this platform")
+
+func mmap(file *os.File, size int) ([]byte, error) {
+	return nil, errNoMmap
+}
+
+func munmap(data []byte) error {
+	return errNoMmap
+}
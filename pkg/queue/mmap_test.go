@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	r, err := OpenMmapRing(path, 4, 3)
+	if err != nil {
+		t.Fatalf("OpenMmapRing should succeed, got %v", err)
+	}
+
+	for _, rec := range []string{"aaaa", "bbbb", "cccc"} {
+		if err := r.Append([]byte(rec)); err != nil {
+			t.Errorf("Append of %s should succeed, got %v", rec, err)
+		}
+	}
+	if err := r.Append([]byte("dddd")); err != ErrFull {
+		t.Errorf("Append to a full ring should return ErrFull, got %v", err)
+	}
+	if err := r.Append([]byte("e")); err != ErrRecordSize {
+		t.Errorf("Append of a short record should return ErrRecordSize, got %v", err)
+	}
+	if rec, err := r.Take(); err != nil || string(rec) != "aaaa" {
+		t.Errorf("There should be aaaa on take, there is %s (%v)", rec, err)
+	}
+	// wraps around the end of the file
+	r.Append([]byte("dddd"))
+	r.Close()
+
+	r, err = OpenMmapRing(path, 4, 3)
+	if err != nil {
+		t.Fatalf("Reopening should succeed, got %v", err)
+	}
+	defer r.Close()
+	if r.Length() != 3 {
+		t.Errorf("Reopened ring length should be 3, it is %d", r.Length())
+	}
+	for _, expected := range []string{"bbbb", "cccc", "dddd"} {
+		if rec, ok := r.TryPop(); !ok || string(rec) != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, rec)
+		}
+	}
+	if _, ok := r.TryPop(); ok {
+		t.Error("TryPop on an empty ring should fail")
+	}
+
+	if _, err := OpenMmapRing(path, 8, 3); err != ErrLayout {
+		t.Errorf("Opening with another layout should return ErrLayout, got %v", err)
+	}
+}
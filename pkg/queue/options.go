@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicate is returned when deduplication is on and an equal element is already queued
+var ErrDuplicate = errors.New("queue: duplicate element")
+
+// Option configures a queue when it is created
+type Option[T any] func(*settings[T])
+
+// settings collects the options a queue is created with
+type settings[T any] struct {
+	dedup          bool
+	onEvict        func(elem T, reason EvictReason)
+	onAppend       func(elem T)
+	onPop          func(elem T)
+	interceptors   []Interceptor[T]
+	clock          Clock
+	visibility     time.Duration
+	deadLetter     *Queue[T]
+	maxDeliveries  int
+	envelopes      bool
+	codec          Codec[T]
+	repair         RepairMode
+	segmentSize    int64
+	expvar         string
+	logger         Logger
+	logLevel       LogLevel
+	longWait       time.Duration
+	latency        bool
+	watermarks     *watermarks
+	unsynchronized bool
+	initial        int
+	capacity       int
+	policy         OverflowPolicy
+	keepBuffer     bool
+	maxBytes       int
+	sizer          Sizer[T]
+	coalesce       *coalescer[T]
+}
+
+func newSettings[T any](opts []Option[T]) settings[T] {
+	s := settings[T]{clock: systemClock{}, visibility: DefaultVisibilityTimeout, codec: GobCodec[T]{}, segmentSize: DefaultSegmentSize, longWait: DefaultLongWait}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// WithDedup turns the queue into an ordered set: an element equal to one that is
+// already queued is not added again. Append and Prepend return the zero Handle for
+// it, TryAppend returns false and AppendContext and InsertAt return ErrDuplicate
+func WithDedup[T comparable]() Option[T] {
+	return func(s *settings[T]) {
+		s.dedup = true
+	}
+}
+
+// WithOnEvict registers a callback that is called for every element the queue
+// drops on its own, with the reason it was dropped. It runs after the queue has
+// been unlocked, in the goroutine whose call caused the eviction
+func WithOnEvict[T any](onEvict func(elem T, reason EvictReason)) Option[T] {
+	return func(s *settings[T]) {
+		s.onEvict = onEvict
+	}
+}
+
+// WithClock makes the queue read the time from clock instead of the system clock.
+// It applies to everything time-based: AppendAt and DelayQueue, visibility timeouts
+// and backoff, envelope timestamps and latency, coalescing windows, Batcher,
+// RateLimited, paced consumers and worker retries
+func WithClock[T any](clock Clock) Option[T] {
+	return func(s *settings[T]) {
+		s.clock = clock
+	}
+}
+
+// duplicate reports whether deduplication is on and elem is already queued
+func (q *Queue[T]) duplicate(elem T) bool {
+	return q.values != nil && q.values.contains(elem)
+}
+
+// WithInitialCapacity sets up the buffer of the queue for n elements, so that a
+// backlog of known size does not cause a series of resizes while it builds up.
+// The buffer does not shrink below that size either. This does not bound the
+// queue, see NewBounded for that
+func WithInitialCapacity[T any](n int) Option[T] {
+	return func(s *settings[T]) {
+		s.initial = n
+	}
+}
+
+// WithoutShrinking keeps the buffer at the largest size it has grown to instead of
+// resizing it as the queue empties, which suits queues whose backlog comes in bursts
+func WithoutShrinking[T any]() Option[T] {
+	return func(s *settings[T]) {
+		s.keepBuffer = true
+	}
+}
+
+// bufferSize returns the smallest power of two buffer size that holds n elements
+func bufferSize(n int) int {
+	size := minQueueLen
+	for size < n {
+		size <<= 1
+	}
+	return size
+}
@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithDedup(t *testing.T) {
+	q := New(WithDedup[string]())
+
+	if q.Append("a") == 0 {
+		t.Error("Append of a new element should return a handle")
+	}
+	if q.Append("a") != 0 {
+		t.Error("Append of a duplicate should return the zero handle")
+	}
+	if q.Prepend("a") != 0 {
+		t.Error("Prepend of a duplicate should return the zero handle")
+	}
+	if q.TryAppend("a") {
+		t.Error("TryAppend of a duplicate should fail")
+	}
+	if err := q.AppendContext(context.Background(), "a"); err != ErrDuplicate {
+		t.Errorf("AppendContext of a duplicate should return ErrDuplicate, got %v", err)
+	}
+	if err := q.InsertAt(0, "a"); err != ErrDuplicate {
+		t.Errorf("InsertAt of a duplicate should return ErrDuplicate, got %v", err)
+	}
+	q.PrependAll("b", "a", "c")
+	q.Append("d")
+
+	if err := q.Set(0, "a"); err != ErrDuplicate {
+		t.Errorf("Set to a duplicate should return ErrDuplicate, got %v", err)
+	}
+	if err := q.Set(2, "a"); err != nil {
+		t.Errorf("Set of an element to itself should succeed, got %v", err)
+	}
+
+	for _, expected := range []string{"b", "c", "a", "d"} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p)
+		}
+	}
+
+	if !q.TryAppend("a") {
+		t.Error("an element can be added again once it has been popped")
+	}
+}
+
+func TestWithDedupMerge(t *testing.T) {
+	q := NewBounded(10, WithDedup[int]())
+	other := New[int]()
+
+	q.Append(1)
+	other.Append(1)
+	other.Append(2)
+
+	if moved := q.MergeFrom(other); moved != 1 {
+		t.Errorf("MergeFrom should move 1 element, it moved %d", moved)
+	}
+	if other.Length() != 1 || other.Front() != 1 {
+		t.Error("the duplicate should stay in other")
+	}
+}
+
+func TestWithDedupNonComparable(t *testing.T) {
+	assertPanics(t, "WithDedup", func() {
+		NewAny(WithDedup[int]())
+	})
+}
+
+func TestWithInitialCapacity(t *testing.T) {
+	q := New[int](WithInitialCapacity[int](100))
+	if c := q.Stats().BufferCapacity; c != 128 {
+		t.Errorf("Buffer should hold 128 elements, it holds %d", c)
+	}
+	for i := 0; i < 100; i++ {
+		q.Append(i)
+	}
+	if r := q.Stats().Resizes; r != 0 {
+		t.Errorf("Appending up to the initial capacity should not resize, it resized %d times", r)
+	}
+	for i := 0; i < 100; i++ {
+		q.Pop()
+	}
+	if c := q.Stats().BufferCapacity; c != 128 {
+		t.Errorf("Buffer should not shrink below the initial capacity, it holds %d", c)
+	}
+}
+
+func TestWithBound(t *testing.T) {
+	q := New[int](WithBound[int](2), WithOverflowPolicy[int](OverflowDropOldest))
+	for i := 1; i <= 3; i++ {
+		q.Append(i)
+	}
+	if q.Capacity() != 2 || q.Length() != 2 || q.Front() != 2 {
+		t.Errorf("Queue should hold 2 and 3, it holds %v", q.ToSlice())
+	}
+
+	assertPanics(t, "WithBound", func() {
+		WithBound[int](0)
+	})
+}
+
+func TestWithoutShrinking(t *testing.T) {
+	q := New[int](WithoutShrinking[int]())
+	for i := 0; i < 100; i++ {
+		q.Append(i)
+	}
+	grown := q.Stats().BufferCapacity
+	for i := 0; i < 100; i++ {
+		q.Pop()
+	}
+	if c := q.Stats().BufferCapacity; c != grown {
+		t.Errorf("Buffer should keep its size of %d, it is %d", grown, c)
+	}
+}
@@ -0,0 +1,34 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// DrainPaced pops the elements one at a time and passes them to fn, waiting
+// interval between them, until the queue is empty or ctx is done. It does not wait
+// for new elements once the queue is empty. This works off a large backlog in the
+// background without flooding whatever fn sends the elements to.
+// It returns ctx.Err() if ctx is done first and nil otherwise. The time is read
+// from the clock of the queue, see WithClock
+func (q *Queue[T]) DrainPaced(ctx context.Context, interval time.Duration, fn func(T)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		elem, ok := q.tryTake()
+		if !ok {
+			return nil
+		}
+		fn(elem)
+		if q.Length() == 0 {
+			return nil
+		}
+
+		select {
+		case <-q.clock.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
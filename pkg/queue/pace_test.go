@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDrainPaced(t *testing.T) {
+	clock := newFakeClock()
+	q := New[int](WithClock[int](clock))
+	for i := 0; i < 3; i++ {
+		q.Append(i)
+	}
+
+	handled := make(chan int, 3)
+	done := make(chan error)
+	go func() {
+		done <- q.DrainPaced(context.Background(), time.Second, func(elem int) {
+			handled <- elem
+		})
+	}()
+
+	for i := 0; i < 3; i++ {
+		if elem := <-handled; elem != i {
+			t.Errorf("There should be %d handled, there is %d", i, elem)
+		}
+		if i < 2 {
+			clock.waitForWaiters(1)
+			select {
+			case elem := <-handled:
+				t.Fatalf("%d should wait for the interval", elem)
+			default:
+			}
+			clock.Advance(time.Second)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Errorf("DrainPaced should return nil once the queue is empty, got %v", err)
+	}
+}
+
+func TestDrainPacedCancel(t *testing.T) {
+	q := New[int]()
+	q.Append(1)
+	q.Append(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := q.DrainPaced(ctx, time.Hour, func(int) {
+		cancel()
+	})
+	if err != context.Canceled || q.Length() != 1 {
+		t.Errorf("DrainPaced should stop at the cancel with 1 element left, got %v and %d", err, q.Length())
+	}
+}
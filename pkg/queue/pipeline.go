@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline runs a chain of stages, each consuming one queue with a pool of
+// workers and feeding its results into the next. Stages are added with AddStage
+// and AddSink and started together by Run.
+// Closing the first queue shuts the pipeline down in order: every stage closes
+// its output queue once its input is closed and drained and its workers are
+// done, so no element is lost on the way. The first error a stage returns stops
+// the whole pipeline instead
+type Pipeline struct {
+	stages []func(ctx context.Context)
+	mutex  sync.Mutex
+	err    error
+	cancel context.CancelFunc
+}
+
+// NewPipeline creates a Pipeline without stages
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// AddStage adds a stage that passes the elements of in to fn in n workers and
+// appends the results to a new queue, created with opts, which it returns as
+// the input of the next stage. Panics if n is not positive
+func AddStage[In, Out any](p *Pipeline, in *Queue[In], n int, fn func(context.Context, In) (Out, error), opts ...Option[Out]) *Queue[Out] {
+	if n <= 0 {
+		panic("queue: number of workers must be positive")
+	}
+	out := NewAny[Out](opts...)
+	p.stages = append(p.stages, func(ctx context.Context) {
+		in.RunWorkers(ctx, n, func(ctx context.Context, elem In) error {
+			result, err := fn(ctx, elem)
+			if err == nil {
+				err = out.AppendContext(ctx, result)
+			}
+			if err != nil {
+				p.fail(err)
+			}
+			return err
+		})
+		out.Close()
+	})
+	return out
+}
+
+// AddSink adds the last stage, which passes the elements of in to fn in n workers.
+// Panics if n is not positive
+func AddSink[In any](p *Pipeline, in *Queue[In], n int, fn func(context.Context, In) error) {
+	if n <= 0 {
+		panic("queue: number of workers must be positive")
+	}
+	p.stages = append(p.stages, func(ctx context.Context) {
+		in.RunWorkers(ctx, n, func(ctx context.Context, elem In) error {
+			err := fn(ctx, elem)
+			if err != nil {
+				p.fail(err)
+			}
+			return err
+		})
+	})
+}
+
+// fail stops the pipeline with err, unless it already stopped
+func (p *Pipeline) fail(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.err == nil {
+		p.err = err
+		p.cancel()
+	}
+}
+
+// Run starts every stage and blocks until all of them are done. It returns the
+// first error a stage returned, ctx.Err() if ctx was done first, or nil once the
+// pipeline has shut down after its first queue was closed
+func (p *Pipeline) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.cancel = cancel
+
+	var wg sync.WaitGroup
+	for _, stage := range p.stages {
+		wg.Add(1)
+		go func(stage func(context.Context)) {
+			defer wg.Done()
+			stage(ctx)
+		}(stage)
+	}
+	wg.Wait()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline()
+	numbers := New[int]()
+	squares := AddStage(p, numbers, 2, func(_ context.Context, n int) (int, error) {
+		return n * n, nil
+	})
+	texts := AddStage(p, squares, 2, func(_ context.Context, n int) (string, error) {
+		return strconv.Itoa(n), nil
+	})
+
+	var mutex sync.Mutex
+	var results []string
+	AddSink(p, texts, 1, func(_ context.Context, s string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		results = append(results, s)
+		return nil
+	})
+
+	for i := 1; i <= 10; i++ {
+		numbers.Append(i)
+	}
+	numbers.Close()
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Errorf("Run should return nil, got %v", err)
+	}
+	if len(results) != 10 {
+		t.Errorf("Every element should reach the sink, got %v", results)
+	}
+	if !squares.Closed() || !texts.Closed() {
+		t.Error("The stages should close their output queues")
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	p := NewPipeline()
+	in := New[int]()
+	failure := errors.New("failed")
+	out := AddStage(p, in, 1, func(_ context.Context, n int) (int, error) {
+		if n == 3 {
+			return 0, failure
+		}
+		return n, nil
+	})
+	AddSink(p, out, 1, func(context.Context, int) error {
+		return nil
+	})
+
+	for i := 1; i <= 5; i++ {
+		in.Append(i)
+	}
+	// the first queue is never closed, the error has to stop the pipeline
+	if err := p.Run(context.Background()); err != failure {
+		t.Errorf("Run should return the error of the stage, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package queue
+
+import "sync"
+
+// events holds what happened while the mutex was held, so that it can be reported
+// once the mutex is released. Queues recycle them, so that a queue with hooks does
+// not allocate new ones for every call
+type events[T any] struct {
+	evicted   []eviction[T]
+	appended  []T
+	popped    []T
+	logs      []logEntry
+	crossings []crossing
+}
+
+// pools recycles the events and ring buffers a queue is done with. Buffers are
+// pooled by size, each in a reusable box, so that recycling one does not allocate.
+// The mutex of the queue guards the map
+type pools struct {
+	events  sync.Pool
+	buffers map[int]*sync.Pool
+	// empty boxes, a *[]slot[T] each
+	boxes sync.Pool
+}
+
+// takeEvents moves the recorded events into a recycled events, leaving its emptied
+// slices for the queue to record the next events in
+func (q *Queue[T]) takeEvents() *events[T] {
+	e, _ := q.pools.events.Get().(*events[T])
+	if e == nil {
+		e = &events[T]{}
+	}
+	e.evicted, q.evicted = q.evicted, e.evicted[:0]
+	e.appended, q.appended = q.appended, e.appended[:0]
+	e.popped, q.popped = q.popped, e.popped[:0]
+	e.logs, q.logs = q.logs, e.logs[:0]
+	e.crossings, q.crossings = q.crossings, e.crossings[:0]
+	return e
+}
+
+// report calls the callbacks for e and then hands it back for reuse,
+// the mutex must not be held
+func (q *Queue[T]) report(e *events[T]) {
+	for _, entry := range e.logs {
+		entry.write(q.logger)
+	}
+	for _, ev := range e.evicted {
+		q.onEvict(ev.elem, ev.reason)
+	}
+	for _, elem := range e.appended {
+		q.onAppend(elem)
+	}
+	for _, elem := range e.popped {
+		q.onPop(elem)
+	}
+	for _, c := range e.crossings {
+		if c.high {
+			q.watermarks.onHigh(c.length)
+		} else {
+			q.watermarks.onLow(c.length)
+		}
+	}
+
+	// drop the references to the elements before recycling
+	var zero T
+	for i := range e.evicted {
+		e.evicted[i] = eviction[T]{}
+	}
+	for i := range e.appended {
+		e.appended[i] = zero
+	}
+	for i := range e.popped {
+		e.popped[i] = zero
+	}
+	for i := range e.logs {
+		e.logs[i] = logEntry{}
+	}
+	q.pools.events.Put(e)
+}
+
+// buffer returns an empty ring buffer of size slots, recycled if one is available
+func (q *Queue[T]) buffer(size int) []slot[T] {
+	if p := q.pools.buffers[size]; p != nil {
+		if box, _ := p.Get().(*[]slot[T]); box != nil {
+			buf := *box
+			*box = nil
+			q.pools.boxes.Put(box)
+			return buf
+		}
+	}
+	return make([]slot[T], size)
+}
+
+// recycle hands back buf, which the queue no longer uses. A buffer shared with
+// an ImmutableQueue is left alone
+func (q *Queue[T]) recycle(buf []slot[T]) {
+	if q.frozen {
+		return
+	}
+	for i := range buf {
+		buf[i] = slot[T]{}
+	}
+	box, _ := q.pools.boxes.Get().(*[]slot[T])
+	if box == nil {
+		box = new([]slot[T])
+	}
+	*box = buf
+
+	p := q.pools.buffers[len(buf)]
+	if p == nil {
+		if q.pools.buffers == nil {
+			q.pools.buffers = make(map[int]*sync.Pool)
+		}
+		p = &sync.Pool{}
+		q.pools.buffers[len(buf)] = p
+	}
+	p.Put(box)
+}
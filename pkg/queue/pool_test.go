@@ -0,0 +1,41 @@
+package queue
+
+import "testing"
+
+func TestHooksReuseEvents(t *testing.T) {
+	appended := 0
+	q := New[int](WithOnAppend(func(int) { appended++ }), WithOnPop(func(int) {}))
+	q.Append(0)
+	q.Pop()
+
+	calls := 1
+	assertNoAllocs(t, "Append and Pop with hooks", func() {
+		calls++
+		q.Append(1)
+		q.Pop()
+	})
+	if appended != calls {
+		t.Errorf("OnAppend should be called %d times, it was called %d times", calls, appended)
+	}
+}
+
+func TestRecycledBufferKeepsFrozenView(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < minQueueLen; i++ {
+		q.Append(i)
+	}
+	view := q.Freeze()
+	for i := 0; i < 4*minQueueLen; i++ {
+		q.Append(-1)
+	}
+	q.Clean()
+	for i := 0; i < 4*minQueueLen; i++ {
+		q.Append(-2)
+	}
+
+	for i, elem := range view.ToSlice() {
+		if elem != i {
+			t.Fatalf("Frozen view should keep element %d, it has %d", i, elem)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOutOfRange is returned when a position does not exist in the queue
+var ErrOutOfRange = errors.New("queue: index out of range")
+
+// IndexOf returns the position of elem counted from the front of the queue,
+// or -1 if it is not queued. If elem is queued more than once the position
+// closest to the front is returned.
+// Panics on a queue created by NewAny
+func (q *Queue[T]) IndexOf(elem T) int {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	equal := q.matcher()
+	i, found := 0, -1
+	q.walk(func(_ int, queued T) bool {
+		if equal(queued, elem) {
+			found = i
+			return false
+		}
+		i++
+		return true
+	})
+	return found
+}
+
+// locate returns the buffer position of the i-th element from the front,
+// the mutex must be held
+func (q *Queue[T]) locate(i int) (int, bool) {
+	if i < 0 || i >= q.count {
+		return 0, false
+	}
+	return (q.head + i) & (len(q.buf) - 1), true
+}
+
+// PeekAt returns the i-th element from the front without removing it.
+// It returns false if i is out of range
+func (q *Queue[T]) PeekAt(i int) (T, bool) {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	pos, ok := q.locate(i)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return q.buf[pos].elem, true
+}
+
+// InsertAt adds elem so that it ends up at position i counted from the front,
+// 0 inserts at the front and Length() at the back. It returns ErrOutOfRange for
+// any other position. A full bounded queue applies its OverflowPolicy like Append
+// and ErrDuplicate is returned if deduplication is on and elem is already queued
+func (q *Queue[T]) InsertAt(i int, elem T) error {
+	elem, err := q.intercept(OpInsert, elem)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if i < 0 || i > q.size {
+		return ErrOutOfRange
+	}
+	if err := q.admit(context.Background(), elem, true); err != nil {
+		return err
+	}
+	// making room may have evicted elements
+	if i > q.size {
+		i = q.size
+	}
+	q.insert(i, elem)
+	return nil
+}
+
+// insert adds elem at position i, shifting everything behind it towards the back
+func (q *Queue[T]) insert(i int, elem T) int64 {
+	id := q.newId()
+	q.place(i, id, elem)
+	q.added(elem)
+	return id
+}
+
+// place stores elem under id at position i counted from the front
+func (q *Queue[T]) place(i int, id int64, elem T) {
+	q.thaw()
+	switch {
+	case i <= 0:
+		q.pushFront(slot[T]{id, elem})
+	case i >= q.size:
+		q.pushBack(slot[T]{id, elem})
+	default:
+		if q.count == len(q.buf) {
+			q.resize()
+		}
+
+		pos, _ := q.locate(i)
+		mask := len(q.buf) - 1
+		for j := q.tail; j != pos; j = (j - 1) & mask {
+			q.buf[j] = q.buf[(j-1)&mask]
+		}
+		q.buf[pos] = slot[T]{id, elem}
+		// bitwise modulus
+		q.tail = (q.tail + 1) & mask
+		q.count++
+	}
+	q.store(id, elem)
+
+	q.notify()
+
+	if q.count == 1 {
+		q.notEmpty.Broadcast()
+	}
+}
+
+// Set overwrites the element at position i counted from the front, keeping its
+// place in the queue and its Handle. It returns ErrOutOfRange if i does not exist,
+// and ErrDuplicate if deduplication is on and elem is queued at another position
+func (q *Queue[T]) Set(i int, elem T) error {
+	elem, err := q.intercept(OpSet, elem)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	pos, ok := q.locate(i)
+	if !ok {
+		return ErrOutOfRange
+	}
+	if q.duplicate(elem) {
+		if other, _ := q.lookup(elem); other != pos {
+			return ErrDuplicate
+		}
+	}
+	q.replace(pos, elem)
+	return nil
+}
+
+// replace overwrites the element at buffer position pos, keeping its id and metadata
+func (q *Queue[T]) replace(pos int, elem T) {
+	q.thaw()
+	s := q.buf[pos]
+	meta, ok := q.meta[s.id]
+	q.forget(s.id, s.elem)
+	q.store(s.id, elem)
+	q.buf[pos].elem = elem
+	if ok {
+		q.setMeta(s.id, meta)
+	}
+}
+
+// Upsert replaces the queued element equal to elem in place, keeping its position
+// and Handle, or appends elem if there is none. It returns true if an element was replaced.
+// Panics on a queue created by NewAny
+func (q *Queue[T]) Upsert(elem T) bool {
+	elem, err := q.intercept(OpUpsert, elem)
+	if err != nil {
+		return false
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if pos, ok := q.lookup(elem); ok {
+		q.replace(pos, elem)
+		return true
+	}
+	if q.admitAppend(context.Background(), elem, true) == nil {
+		q.append(elem)
+	}
+	return false
+}
+
+// UpsertFunc replaces the first queued element for which match returns true with elem,
+// keeping its position and Handle, or appends elem if there is none. This coalesces
+// elements that share a key, for example repeated updates of the same record.
+// It returns true if an element was replaced
+func (q *Queue[T]) UpsertFunc(elem T, match func(queued T) bool) bool {
+	elem, err := q.intercept(OpUpsert, elem)
+	if err != nil {
+		return false
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	replaced := false
+	q.walk(func(pos int, queued T) bool {
+		if match(queued) {
+			q.replace(pos, elem)
+			replaced = true
+			return false
+		}
+		return true
+	})
+	if !replaced && q.admitAppend(context.Background(), elem, true) == nil {
+		q.append(elem)
+	}
+	return replaced
+}
+
+// Rotate moves the first n elements to the back of the queue, keeping their order
+// and their handles. A negative n moves the last -n elements to the front instead
+func (q *Queue[T]) Rotate(n int) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	length := q.size
+	if length == 0 {
+		return
+	}
+	n %= length
+	if n < 0 {
+		n += length
+	}
+
+	// move whichever side is shorter
+	if n <= length/2 {
+		for ; n > 0; n-- {
+			q.pushBack(q.popFront())
+		}
+		return
+	}
+	for n = length - n; n > 0; n-- {
+		q.pushFront(q.popBack())
+	}
+}
+
+// Swap exchanges the elements at positions i and j counted from the front.
+// It returns ErrOutOfRange if either position does not exist
+func (q *Queue[T]) Swap(i, j int) error {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	pi, ok := q.locate(i)
+	if !ok {
+		return ErrOutOfRange
+	}
+	pj, ok := q.locate(j)
+	if !ok {
+		return ErrOutOfRange
+	}
+	q.swapElem(pi, pj)
+	return nil
+}
+
+// find returns the buffer position of the slot holding id. Slots move whenever a
+// gap is closed or the queue is reordered, so it scans rather than keeping an index
+func (q *Queue[T]) find(id int64) (int, bool) {
+	mask := len(q.buf) - 1
+	for n := 0; n < q.count; n++ {
+		pos := (q.head + n) & mask
+		if q.buf[pos].id == id {
+			return pos, true
+		}
+	}
+	return 0, false
+}
+
+// MoveToFront moves elem to the front of the queue, keeping its Handle.
+// If elem is queued more than once the occurrence closest to the front is moved.
+// It returns false if elem is not queued. Panics on a queue created by NewAny
+func (q *Queue[T]) MoveToFront(elem T) bool {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	pos, ok := q.lookup(elem)
+	if !ok {
+		return false
+	}
+	q.pushFront(q.cut(pos))
+	return true
+}
+
+// MoveToBack moves elem to the back of the queue, keeping its Handle.
+// If elem is queued more than once the occurrence closest to the front is moved.
+// It returns false if elem is not queued. Panics on a queue created by NewAny
+func (q *Queue[T]) MoveToBack(elem T) bool {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	pos, ok := q.lookup(elem)
+	if !ok {
+		return false
+	}
+	q.pushBack(q.cut(pos))
+	return true
+}
@@ -0,0 +1,306 @@
+package queue
+
+import "testing"
+
+func TestIndexOf(t *testing.T) {
+	q := New[string]()
+
+	if i := q.IndexOf("a"); i != -1 {
+		t.Errorf("IndexOf on an empty queue should be -1, it is %d", i)
+	}
+
+	q.Append("a")
+	q.Append("b")
+	q.Append("c")
+	q.Prepend("c")
+
+	if i := q.IndexOf("c"); i != 0 {
+		t.Errorf("IndexOf c should be 0, it is %d", i)
+	}
+	if i := q.IndexOf("b"); i != 2 {
+		t.Errorf("IndexOf b should be 2, it is %d", i)
+	}
+
+	q.Remove("a")
+	q.Pop()
+	if i := q.IndexOf("b"); i != 0 {
+		t.Errorf("IndexOf b should be 0, it is %d", i)
+	}
+	if i := q.IndexOf("c"); i != 1 {
+		t.Errorf("IndexOf c should be 1, it is %d", i)
+	}
+	if i := q.IndexOf("a"); i != -1 {
+		t.Errorf("IndexOf a should be -1, it is %d", i)
+	}
+}
+
+func TestPeekAt(t *testing.T) {
+	q := New[int]()
+
+	if _, ok := q.PeekAt(0); ok {
+		t.Error("PeekAt on an empty queue should fail")
+	}
+
+	for i := 0; i < 40; i++ {
+		q.Append(i)
+	}
+	q.Pop()
+	q.Prepend(100)
+
+	for i, expected := range []int{100, 1, 2} {
+		if p, ok := q.PeekAt(i); !ok || p != expected {
+			t.Errorf("There should be %d at %d, there is %v", expected, i, p)
+		}
+	}
+	if p, ok := q.PeekAt(39); !ok || p != 39 {
+		t.Errorf("There should be 39 at 39, there is %v", p)
+	}
+	if _, ok := q.PeekAt(40); ok {
+		t.Error("PeekAt past the back should fail")
+	}
+	if _, ok := q.PeekAt(-1); ok {
+		t.Error("PeekAt with a negative index should fail")
+	}
+
+	q.Remove(1)
+	q.Remove(3)
+	for i, expected := range []int{100, 2, 4, 5} {
+		if p, ok := q.PeekAt(i); !ok || p != expected {
+			t.Errorf("There should be %d at %d, there is %v", expected, i, p)
+		}
+	}
+	if q.Length() != 38 {
+		t.Errorf("Queue length should be 38, it is %d", q.Length())
+	}
+}
+
+func TestInsertAt(t *testing.T) {
+	q := New[int]()
+
+	if err := q.InsertAt(1, 1); err != ErrOutOfRange {
+		t.Errorf("InsertAt past the back should fail, got %v", err)
+	}
+	if err := q.InsertAt(0, 2); err != nil {
+		t.Errorf("InsertAt on an empty queue should succeed, got %v", err)
+	}
+	q.Append(4)
+	q.InsertAt(1, 3)
+	q.InsertAt(0, 1)
+	q.InsertAt(4, 5)
+
+	// force the ring to wrap before inserting in the middle
+	for i := 6; i < 40; i++ {
+		q.Append(i)
+	}
+	q.Pop()
+	q.Prepend(1)
+	q.Remove(3)
+	q.InsertAt(2, 3)
+
+	for expected := 1; expected < 40; expected++ {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestInsertAtBounded(t *testing.T) {
+	q := NewBoundedWithPolicy[int](2, OverflowReject)
+
+	q.Append(1)
+	q.Append(3)
+	if err := q.InsertAt(1, 2); err != ErrFull {
+		t.Errorf("InsertAt on a full queue should fail, got %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	q := New[int]()
+
+	if err := q.Set(0, 1); err != ErrOutOfRange {
+		t.Errorf("Set on an empty queue should fail, got %v", err)
+	}
+
+	q.Append(1)
+	h := q.Append(2)
+	q.Append(3)
+
+	if err := q.Set(1, 20); err != nil {
+		t.Errorf("Set should succeed, got %v", err)
+	}
+	if q.IndexOf(2) != -1 || q.IndexOf(20) != 1 {
+		t.Error("Set should replace the element in the index")
+	}
+	if q.Length() != 3 {
+		t.Errorf("Queue length should be 3, it is %d", q.Length())
+	}
+	if !q.RemoveByHandle(h) {
+		t.Error("Set should keep the handle of the element")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	q := New[int]()
+
+	q.Rotate(3)
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	h := q.Append(10)
+	q.Remove(10)
+
+	check := func(expected ...int) {
+		s := q.ToSlice()
+		for i := range expected {
+			if s[i] != expected[i] {
+				t.Errorf("queue should start with %v, it is %v", expected, s)
+				return
+			}
+		}
+	}
+
+	q.Rotate(3)
+	check(3, 4, 5, 6, 7, 8, 9, 0, 1, 2)
+	q.Rotate(-3)
+	check(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	q.Rotate(18)
+	check(8, 9, 0, 1, 2, 3, 4, 5, 6, 7)
+	q.Rotate(12)
+	check(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+
+	if q.Length() != 10 {
+		t.Errorf("Queue length should be 10, it is %d", q.Length())
+	}
+	if q.RemoveByHandle(h) {
+		t.Error("Rotate should not bring back removed elements")
+	}
+}
+
+func TestSwap(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+	q.Pop()
+	q.Append(5)
+
+	if err := q.Swap(0, 4); err != nil {
+		t.Errorf("Swap should succeed, got %v", err)
+	}
+	if err := q.Swap(1, 1); err != nil {
+		t.Errorf("Swap with itself should succeed, got %v", err)
+	}
+	if err := q.Swap(0, 5); err != ErrOutOfRange {
+		t.Errorf("Swap past the back should fail, got %v", err)
+	}
+
+	for _, expected := range []int{5, 2, 3, 4, 1} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestMoveToFront(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+
+	if !q.MoveToFront(3) {
+		t.Error("MoveToFront should find a queued element")
+	}
+	if q.MoveToFront(7) {
+		t.Error("MoveToFront should not find a missing element")
+	}
+	q.MoveToFront(0)
+
+	if q.Length() != 5 {
+		t.Errorf("Queue length should be 5, it is %d", q.Length())
+	}
+	if i := q.IndexOf(3); i != 1 {
+		t.Errorf("IndexOf 3 should be 1, it is %d", i)
+	}
+	for _, expected := range []int{0, 3, 1, 2, 4} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestMoveToBack(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+
+	if !q.MoveToBack(1) {
+		t.Error("MoveToBack should find a queued element")
+	}
+	if q.MoveToBack(7) {
+		t.Error("MoveToBack should not find a missing element")
+	}
+	q.MoveToBack(4)
+
+	if q.Length() != 5 {
+		t.Errorf("Queue length should be 5, it is %d", q.Length())
+	}
+	if q.Back() != 4 {
+		t.Errorf("There should be 4 on back, there is %v", q.Back())
+	}
+	for _, expected := range []int{0, 2, 3, 1, 4} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	q := New[string]()
+
+	q.Append("a")
+	q.Append("b")
+
+	if !q.Upsert("a") {
+		t.Error("Upsert should replace the queued element")
+	}
+	if q.Upsert("c") {
+		t.Error("Upsert should append a missing element")
+	}
+	for _, expected := range []string{"a", "b", "c"} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p)
+		}
+	}
+}
+
+func TestUpsertFunc(t *testing.T) {
+	type update struct {
+		key   string
+		value int
+	}
+	q := NewAny[update]()
+
+	upsert := func(u update) bool {
+		return q.UpsertFunc(u, func(queued update) bool { return queued.key == u.key })
+	}
+	upsert(update{"a", 1})
+	h := q.Append(update{"b", 1})
+	upsert(update{"c", 1})
+
+	if !upsert(update{"b", 2}) {
+		t.Error("UpsertFunc should replace the element with the same key")
+	}
+	if q.Length() != 3 {
+		t.Errorf("Queue length should be 3, it is %d", q.Length())
+	}
+	if p, _ := q.PeekAt(1); p.value != 2 {
+		t.Errorf("There should be the new value at 1, there is %v", p)
+	}
+	if !q.RemoveByHandle(h) {
+		t.Error("UpsertFunc should keep the handle of the element")
+	}
+}
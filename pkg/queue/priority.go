@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// PriorityQueue is a blocking queue that pops the element with the highest priority first.
+// Elements with equal priority are popped in the order they were pushed
+type PriorityQueue[T any] struct {
+	entries  entryHeap[priorityEntry[T]]
+	seq      uint64
+	mutex    *sync.Mutex
+	notEmpty *sync.Cond
+	closed   bool
+	// You can subscribe to this channel to know whether queue is not empty
+	NotEmpty chan struct{}
+}
+
+type priorityEntry[T any] struct {
+	elem     T
+	priority int
+	// insertion sequence number, keeps equal priorities in FIFO order
+	seq uint64
+}
+
+// entryHeap implements heap.Interface, the entry that less orders first is on top
+type entryHeap[E any] struct {
+	entries []E
+	less    func(a, b *E) bool
+}
+
+func (h *entryHeap[E]) Len() int           { return len(h.entries) }
+func (h *entryHeap[E]) Less(i, j int) bool { return h.less(&h.entries[i], &h.entries[j]) }
+func (h *entryHeap[E]) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *entryHeap[E]) Push(x any) {
+	h.entries = append(h.entries, x.(E))
+}
+
+func (h *entryHeap[E]) Pop() any {
+	var zero E
+	n := len(h.entries) - 1
+	entry := h.entries[n]
+	h.entries[n] = zero
+	h.entries = h.entries[:n]
+	return entry
+}
+
+// NewPriority creates an empty queue that pops the element with the highest
+// priority first, elements with equal priority in the order they were pushed
+func NewPriority[T any]() *PriorityQueue[T] {
+	return newPriority(func(a, b *priorityEntry[T]) int {
+		switch {
+		case a.priority > b.priority:
+			return -1
+		case a.priority < b.priority:
+			return 1
+		}
+		return 0
+	})
+}
+
+// newPriority creates a PriorityQueue ordered by cmp, with FIFO order for equal entries
+func newPriority[T any](cmp func(a, b *priorityEntry[T]) int) *PriorityQueue[T] {
+	less := func(a, b *priorityEntry[T]) bool {
+		if c := cmp(a, b); c != 0 {
+			return c < 0
+		}
+		return a.seq < b.seq
+	}
+	q := &PriorityQueue[T]{
+		entries:  entryHeap[priorityEntry[T]]{less: less},
+		mutex:    &sync.Mutex{},
+		NotEmpty: make(chan struct{}, 1),
+	}
+	q.notEmpty = sync.NewCond(q.mutex)
+	return q
+}
+
+// Returns the number of elements in queue
+func (q *PriorityQueue[T]) Length() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.entries.Len()
+}
+
+func (q *PriorityQueue[T]) notify() {
+	if q.entries.Len() > 0 && !q.closed {
+		select {
+		case q.NotEmpty <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Push adds elem with the given priority, higher priorities are popped first
+// and equal priorities in the order they were pushed. Pushing to a closed queue is a no-op
+func (q *PriorityQueue[T]) Push(elem T, priority int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.seq++
+	heap.Push(&q.entries, priorityEntry[T]{elem: elem, priority: priority, seq: q.seq})
+	q.notify()
+
+	if q.entries.Len() == 1 {
+		q.notEmpty.Broadcast()
+	}
+}
+
+// Previews the element with the highest priority
+func (q *PriorityQueue[T]) Front() T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var result T
+	if q.entries.Len() > 0 {
+		result = q.entries.entries[0].elem
+	}
+	return result
+}
+
+func (q *PriorityQueue[T]) take(ctx context.Context) (T, error) {
+	for q.entries.Len() == 0 {
+		if q.closed {
+			var zero T
+			return zero, ErrClosed
+		}
+		if err := waitContext(ctx, q.notEmpty); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+
+	entry := heap.Pop(&q.entries).(priorityEntry[T])
+	q.notify()
+	return entry.elem, nil
+}
+
+// Pop removes and returns the element with the highest priority.
+// If the queue is empty, it will block. Once the queue is closed and
+// empty it returns the zero value
+func (q *PriorityQueue[T]) Pop() T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	item, _ := q.take(context.Background())
+	return item
+}
+
+// Take works like Pop, but returns ErrClosed instead of the zero value
+// once the queue is closed and empty
+func (q *PriorityQueue[T]) Take() (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (q *PriorityQueue[T]) PopContext(ctx context.Context) (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.take(ctx)
+}
+
+// Close wakes up every goroutine blocked in Pop and stops the queue from
+// accepting new elements, see Queue.Close
+func (q *PriorityQueue[T]) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.NotEmpty)
+	q.notEmpty.Broadcast()
+	return nil
+}
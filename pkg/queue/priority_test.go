@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityOrder(t *testing.T) {
+	q := NewPriority[int]()
+
+	for i := 0; i < 1000; i++ {
+		p := rand.Intn(100)
+		q.Push(p, p)
+	}
+	if q.Length() != 1000 {
+		t.Errorf("Queue length should be 1000, it is %d", q.Length())
+	}
+
+	prev := q.Pop()
+	for q.Length() > 0 {
+		cur := q.Pop()
+		if cur > prev {
+			t.Errorf("priority order is wrong %d > %d", cur, prev)
+		}
+		prev = cur
+	}
+}
+
+func TestPriorityFront(t *testing.T) {
+	q := NewPriority[string]()
+
+	if q.Front() != "" {
+		t.Error("There should be nothing on front")
+	}
+	q.Push("low", 1)
+	q.Push("high", 10)
+	q.Push("normal", 5)
+
+	if q.Front() != "high" {
+		t.Errorf("There should be high on front, there is %s", q.Front())
+	}
+}
+
+func TestPriorityBlocking(t *testing.T) {
+	q := NewPriority[int]()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		if p := q.Pop(); p != 7 {
+			t.Errorf("There should be 7 on pop, there is %v", p)
+		}
+		wg.Done()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Push(7, 0)
+	wg.Wait()
+}
+
+func TestPriorityClose(t *testing.T) {
+	q := NewPriority[int]()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("PopContext should time out, got %v", err)
+	}
+
+	q.Push(1, 1)
+	q.Close()
+	q.Push(2, 2)
+
+	if item, err := q.Take(); err != nil || item != 1 {
+		t.Errorf("There should be 1 on take, there is %v (%v)", item, err)
+	}
+	if _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed, got %v", err)
+	}
+}
+
+func TestPriorityStable(t *testing.T) {
+	type job struct {
+		priority, seq int
+	}
+	q := NewPriority[job]()
+
+	for i := 0; i < 1000; i++ {
+		p := rand.Intn(5)
+		q.Push(job{p, i}, p)
+	}
+
+	prev := q.Pop()
+	for q.Length() > 0 {
+		cur := q.Pop()
+		if cur.priority == prev.priority && cur.seq < prev.seq {
+			t.Errorf("equal priorities should pop in FIFO order, %d came after %d", cur.seq, prev.seq)
+		}
+		prev = cur
+	}
+}
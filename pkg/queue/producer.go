@@ -0,0 +1,47 @@
+package queue
+
+// Producer collects the elements appended by one goroutine and adds them to its
+// queue in groups, locking the queue once per group instead of once per element.
+// Buffered elements are not visible to consumers until they are flushed, so call
+// Flush when a burst ends. A Producer must not be used by more than one goroutine
+type Producer[T any] struct {
+	q   *Queue[T]
+	buf []T
+}
+
+// NewProducer creates a Producer that adds its elements to q in groups of size.
+// Panics if size is not positive
+func NewProducer[T any](q *Queue[T], size int) *Producer[T] {
+	if size <= 0 {
+		panic("queue: producer size must be positive")
+	}
+	return &Producer[T]{q: q, buf: make([]T, 0, size)}
+}
+
+// Append buffers elem, flushing the buffer to the queue once it is full
+func (p *Producer[T]) Append(elem T) {
+	p.buf = append(p.buf, elem)
+	if len(p.buf) == cap(p.buf) {
+		p.Flush()
+	}
+}
+
+// Flush adds the buffered elements to the queue with AppendAll
+func (p *Producer[T]) Flush() {
+	if len(p.buf) == 0 {
+		return
+	}
+	p.q.AppendAll(p.buf...)
+
+	// drop the references before the buffer is reused
+	var zero T
+	for i := range p.buf {
+		p.buf[i] = zero
+	}
+	p.buf = p.buf[:0]
+}
+
+// Buffered returns the number of elements waiting to be flushed
+func (p *Producer[T]) Buffered() int {
+	return len(p.buf)
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestProducer(t *testing.T) {
+	q := New[int]()
+	p := NewProducer(q, 3)
+
+	p.Append(1)
+	p.Append(2)
+	if q.Length() != 0 || p.Buffered() != 2 {
+		t.Errorf("Elements should stay buffered until the producer is full, queue has %d", q.Length())
+	}
+	p.Append(3)
+	if q.Length() != 3 || p.Buffered() != 0 {
+		t.Errorf("A full producer should flush, queue has %d", q.Length())
+	}
+	p.Append(4)
+	p.Flush()
+
+	for i := 1; i <= 4; i++ {
+		if elem := q.Pop(); elem != i {
+			t.Errorf("There should be %d on pop, there is %d", i, elem)
+		}
+	}
+
+	assertPanics(t, "NewProducer", func() {
+		NewProducer(q, 0)
+	})
+}
+
+func TestProducersConcurrent(t *testing.T) {
+	q := New[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := NewProducer(q, 16)
+			for j := 0; j < 1000; j++ {
+				p.Append(j)
+			}
+			p.Flush()
+		}()
+	}
+	wg.Wait()
+
+	if q.Length() != 4000 {
+		t.Errorf("Queue should have 4000 elements, it has %d", q.Length())
+	}
+}
+
+func TestAppendAll(t *testing.T) {
+	q := New[int](WithDedup[int]())
+	q.Append(2)
+	q.AppendAll(1, 2, 3)
+
+	if s := q.ToSlice(); len(s) != 3 || s[0] != 2 || s[1] != 1 || s[2] != 3 {
+		t.Errorf("Queue should hold 2 1 3, it holds %v", s)
+	}
+}
+
+func BenchmarkProducer(b *testing.B) {
+	q := New[int]()
+	p := NewProducer(q, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.Append(i)
+		if p.Buffered() == 0 {
+			q.Clean()
+		}
+	}
+}
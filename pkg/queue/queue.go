@@ -1,56 +1,244 @@
 package queue
 
 import (
-	"math/rand"
+	"context"
+	"errors"
+	"expvar"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const minQueueLen = 32
 
-type Queue[T comparable] struct {
-	items             map[int64]T
-	ids               map[T]int64
-	buf               []int64
-	head, tail, count int
-	mutex             *sync.Mutex
-	notEmpty          *sync.Cond
+// number of queues created so far
+var created uint64
+
+// ErrClosed is returned by Take once the queue has been closed and emptied
+var ErrClosed = errors.New("queue: closed")
+
+type Queue[T any] struct {
+	// size, kept first to be 64-bit aligned for atomic access on 32-bit platforms
+	length int64
+	// ring buffer of count slots starting at head, size of them hold an element
+	buf                     []slot[T]
+	head, tail, count, size int
+	// the buffer never shrinks below this size, see WithInitialCapacity
+	minBuf int
+	// the buffer does not shrink at all, see WithoutShrinking
+	keepBuffer bool
+	// id given to the last element added, see Handle
+	lastId int64
+	// reports whether two elements are equal, nil for a queue created by NewAny
+	equal func(elem1, elem2 T) bool
+	// counts the queued elements by value while deduplication is on
+	values index[T]
+	// buf is shared with an ImmutableQueue, see Freeze
+	frozen bool
+	// recycled events and buffers, see pools
+	pools *pools
+	mutex sync.Locker
+	// read lock of mutex, for methods that do not change the queue
+	rmutex   sync.Locker
+	notEmpty *sync.Cond
+	// broadcast whenever elements are removed
+	notFull *sync.Cond
+	// broadcast whenever elements are added
+	grown    *sync.Cond
+	capacity int
+	policy   OverflowPolicy
+	// the byte limit set by WithMaxBytes and the size of the queued elements
+	maxBytes int
+	bytes    int
+	sizer    Sizer[T]
+	closed   bool
+	dedup    bool
+	// keeps the queue sorted when set, see NewSorted
+	cmp func(elem1 T, elem2 T) int
+	// eviction callback and the evictions it has not been called for yet
+	onEvict func(elem T, reason EvictReason)
+	evicted []eviction[T]
+	// lifecycle hooks and the elements they have not been called for yet
+	onAppend, onPop  func(elem T)
+	appended, popped []T
+	interceptors     []Interceptor[T]
+	// running totals reported by Stats
+	appends, pops, removes, resizes uint64
+	peak                            int
+	latency                         bool
+	latencies                       Histogram
+	logger                          Logger
+	logLevel                        LogLevel
+	longWait                        time.Duration
+	watermarks                      *watermarks
+	coalesce                        *coalescer[T]
+	crossings                       []crossing
+	// events waiting to be logged once the mutex is released
+	logs  []logEntry
+	clock Clock
+	// elements handed out by Reserve that have not been acked yet
+	reserved   map[int64]*reservation[T]
+	reserves   uint64
+	visibility time.Duration
+	// where elements go after maxDeliveries failed deliveries, see WithDeadLetter
+	deadLetter    *Queue[T]
+	maxDeliveries int
+	// metadata of the queued elements that have any
+	meta      map[int64]metadata
+	envelopes bool
+	codec     Codec[T]
+	// order in which queues were created, used to lock several queues without deadlocks
+	order uint64
+	// channels handed out by Subscribe
+	subscribers map[<-chan struct{}]chan struct{}
+	// channels handed out by Watch and the length last sent to them
+	watchers map[<-chan int]chan int
+	watched  int
 	// You can subscribe to this channel to know whether queue is not empty
 	NotEmpty chan struct{}
 }
 
-func New[T comparable]() *Queue[T] {
+// Handle identifies one queued element, even when equal elements are queued.
+// It is returned by Append and Prepend and can be passed to RemoveByHandle,
+// the zero Handle never identifies an element
+type Handle int64
+
+// New creates a queue configured by opts, an unbounded queue without them.
+// WithBound and WithOverflowPolicy bound it, WithInitialCapacity and
+// WithoutShrinking size its buffer, WithClock, WithOnAppend, WithOnPop,
+// WithOnEvict and WithLogger hook into it
+func New[T comparable](opts ...Option[T]) *Queue[T] {
+	return newQueue[T](equal[T], valueIndex[T]{}, opts...)
+}
+
+// NewAny creates a queue for element types that are not comparable, such as
+// slices, maps or structs containing them. Such a queue cannot look elements up
+// by value, remove them with the Handle returned by Append instead
+func NewAny[T any](opts ...Option[T]) *Queue[T] {
+	return newQueue[T](nil, nil, opts...)
+}
+
+// newQueue creates a queue that compares elements with equal and counts them
+// in values if deduplication is on, either may be nil
+func newQueue[T any](equal func(elem1, elem2 T) bool, values index[T], opts ...Option[T]) *Queue[T] {
+	s := newSettings(opts)
 	q := &Queue[T]{
-		items:    make(map[int64]T),
-		ids:      make(map[T]int64),
-		buf:      make([]int64, minQueueLen),
-		mutex:    &sync.Mutex{},
-		NotEmpty: make(chan struct{}, 1),
+		equal:         equal,
+		minBuf:        bufferSize(s.initial),
+		keepBuffer:    s.keepBuffer,
+		capacity:      s.capacity,
+		policy:        s.policy,
+		maxBytes:      s.maxBytes,
+		sizer:         s.sizer,
+		NotEmpty:      make(chan struct{}, 1),
+		order:         atomic.AddUint64(&created, 1),
+		dedup:         s.dedup,
+		onEvict:       s.onEvict,
+		onAppend:      s.onAppend,
+		onPop:         s.onPop,
+		interceptors:  s.interceptors,
+		clock:         s.clock,
+		visibility:    s.visibility,
+		deadLetter:    s.deadLetter,
+		maxDeliveries: s.maxDeliveries,
+		envelopes:     s.envelopes,
+		codec:         s.codec,
+		logger:        s.logger,
+		logLevel:      s.logLevel,
+		longWait:      s.longWait,
+		latency:       s.latency,
 	}
 
+	q.pools = &pools{}
+	q.buf = make([]slot[T], q.minBuf)
+	if s.unsynchronized {
+		q.mutex, q.rmutex = noLock{}, noLock{}
+	} else {
+		rw := &sync.RWMutex{}
+		q.mutex, q.rmutex = rw, rw.RLocker()
+	}
 	q.notEmpty = sync.NewCond(q.mutex)
+	q.notFull = sync.NewCond(q.mutex)
+	q.grown = sync.NewCond(q.mutex)
+
+	if q.dedup {
+		// fail early on a queue that cannot look elements up by value
+		q.matcher()
+		q.values = values
+	}
+	if s.watermarks != nil {
+		// every queue keeps track of its own crossings
+		w := *s.watermarks
+		q.watermarks = &w
+	}
+	if s.coalesce != nil {
+		c := *s.coalesce
+		c.recent = make(map[any]recentAppend)
+		q.coalesce = &c
+	}
+	if s.expvar != "" {
+		expvar.Publish(s.expvar, expvar.Func(q.expvarStats))
+	}
 
 	return q
 }
 
-// Removes all elements from queue
+// Removes all elements from queue, they are reported to the eviction callback
 func (q *Queue[T]) Clean() {
 	q.mutex.Lock()
-	defer q.mutex.Unlock()
+	defer q.unlock()
+
+	if q.onEvict != nil {
+		q.walk(func(_ int, elem T) bool {
+			q.evicted = append(q.evicted, eviction[T]{elem, EvictClean})
+			return true
+		})
+	}
+	q.removes += uint64(q.size)
+	if q.logging(LogInfo) {
+		q.log(LogInfo, "queue: cleared", "reason", EvictClean, "count", q.size)
+	}
+	q.reset()
+}
+
+// Drain removes all elements from the queue and returns them from front to back
+func (q *Queue[T]) Drain() []T {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	result := make([]T, 0, q.size)
+	q.walk(func(_ int, elem T) bool {
+		result = append(result, elem)
+		return true
+	})
+	q.pops += uint64(len(result))
+	q.reset()
+	return result
+}
 
-	q.items = make(map[int64]T)
-	q.ids = make(map[T]int64)
-	q.buf = make([]int64, minQueueLen)
+func (q *Queue[T]) reset() {
+	q.recycle(q.buf)
+	q.frozen = false
+	q.size = 0
+	q.bytes = 0
+	atomic.StoreInt64(&q.length, 0)
+	q.meta = nil
+	if q.values != nil {
+		q.values.reset()
+	}
+	if q.coalesce != nil {
+		q.coalesce.recent = make(map[any]recentAppend)
+	}
+	q.buf = q.buffer(q.minBuf)
 	q.tail = 0
 	q.head = 0
 	q.count = 0
+	q.removed()
 }
 
-// Returns the number of elements in queue
+// Returns the number of elements in queue, it is read without locking the queue
 func (q *Queue[T]) Length() int {
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-
-	return len(q.items)
+	return int(atomic.LoadInt64(&q.length))
 }
 
 // resizes the queue to fit exactly twice its current contents
@@ -62,7 +250,11 @@ func (q *Queue[T]) resize() {
 		newCount = newCount << 2
 	}
 
-	newBuf := make([]int64, newCount)
+	newBuf := q.buffer(newCount)
+	q.resizes++
+	if q.logging(LogDebug) {
+		q.log(LogDebug, "queue: resized buffer", "from", len(q.buf), "to", newCount)
+	}
 
 	if q.tail > q.head {
 		copy(newBuf, q.buf[q.head:q.tail])
@@ -71,107 +263,199 @@ func (q *Queue[T]) resize() {
 		copy(newBuf[n:], q.buf[:q.tail])
 	}
 
+	q.recycle(q.buf)
+	// the new buffer is not shared, even if the old one was
+	q.frozen = false
 	q.head = 0
 	q.tail = q.count
 	q.buf = newBuf
 }
 
+// removed wakes up the goroutines waiting for elements to be removed
+func (q *Queue[T]) removed() {
+	q.notFull.Broadcast()
+	q.publish()
+	q.checkWatermarks()
+}
+
 func (q *Queue[T]) notify() {
-	if len(q.items) > 0 {
+	q.grown.Broadcast()
+	q.publish()
+	q.checkWatermarks()
+	if q.size > 0 && !q.closed {
 		select {
 		case q.NotEmpty <- struct{}{}:
 		default:
 		}
+		for _, c := range q.subscribers {
+			signal(c)
+		}
 	}
 }
 
-// Adds one element at the back of the queue
-func (q *Queue[T]) Append(elem T) {
+// Adds one element at the back of the queue and returns its Handle.
+// A full bounded queue applies its OverflowPolicy. Appending to a closed queue,
+// or a duplicate while deduplication is on, is a no-op and returns the zero Handle
+func (q *Queue[T]) Append(elem T) Handle {
+	elem, err := q.intercept(OpAppend, elem)
+	if err != nil {
+		return 0
+	}
+
 	q.mutex.Lock()
-	defer q.mutex.Unlock()
+	defer q.unlock()
 
-	if q.count == len(q.buf) {
-		q.resize()
+	if q.admitAppend(context.Background(), elem, true) != nil {
+		return 0
+	}
+	return Handle(q.append(elem))
+}
+
+// AppendAll adds elems at the back of the queue in one go, keeping their order.
+// A full bounded queue applies its OverflowPolicy for every element, if that has
+// to wait other operations may run in between
+func (q *Queue[T]) AppendAll(elems ...T) {
+	if len(q.interceptors) > 0 {
+		kept := make([]T, 0, len(elems))
+		for _, elem := range elems {
+			if elem, err := q.intercept(OpAppend, elem); err == nil {
+				kept = append(kept, elem)
+			}
+		}
+		elems = kept
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	for _, elem := range elems {
+		if err := q.admitAppend(context.Background(), elem, true); err == ErrDuplicate {
+			continue
+		} else if err != nil {
+			return
+		}
+		q.append(elem)
+	}
+}
+
+func (q *Queue[T]) append(elem T) int64 {
+	if pos, ok := q.coalescing(elem); ok {
+		return q.coalesceInto(pos, elem)
+	}
+	if q.cmp != nil {
+		return q.remember(q.insert(q.search(elem, false), elem), elem)
 	}
 
 	id := q.newId()
-	q.items[id] = elem
-	q.ids[elem] = id
-	q.buf[q.tail] = id
-	// bitwise modulus
-	q.tail = (q.tail + 1) & (len(q.buf) - 1)
-	q.count++
+	q.store(id, elem)
+	q.pushBack(slot[T]{id, elem})
+	q.added(elem)
 
 	q.notify()
 
 	if q.count == 1 {
 		q.notEmpty.Broadcast()
 	}
+	return q.remember(id, elem)
 }
 
 func (q *Queue[T]) newId() int64 {
-	for {
-		id := rand.Int63()
-		_, ok := q.items[id]
-		if id != 0 && !ok {
-			return id
-		}
+	q.lastId++
+	return q.lastId
+}
+
+// Adds one element at the front of queue and returns its Handle.
+// A full bounded queue applies its OverflowPolicy. Prepending to a closed queue,
+// or a duplicate while deduplication is on, is a no-op and returns the zero Handle
+func (q *Queue[T]) Prepend(elem T) Handle {
+	elem, err := q.intercept(OpPrepend, elem)
+	if err != nil {
+		return 0
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if q.admit(context.Background(), elem, true) != nil {
+		return 0
 	}
+	return Handle(q.prepend(elem))
 }
 
-// Adds one element at the front of queue
-func (q *Queue[T]) Prepend(elem T) {
+// PrependAll adds elems at the front of the queue in one go, so that elems[0]
+// ends up at the front. A full bounded queue applies its OverflowPolicy for every
+// element, if that has to wait other operations may run in between
+func (q *Queue[T]) PrependAll(elems ...T) {
+	if len(q.interceptors) > 0 {
+		kept := make([]T, 0, len(elems))
+		for _, elem := range elems {
+			if elem, err := q.intercept(OpPrepend, elem); err == nil {
+				kept = append(kept, elem)
+			}
+		}
+		elems = kept
+	}
+
 	q.mutex.Lock()
-	defer q.mutex.Unlock()
+	defer q.unlock()
 
-	if q.count == len(q.buf) {
-		q.resize()
+	for i := len(elems) - 1; i >= 0; i-- {
+		if err := q.admit(context.Background(), elems[i], true); err == ErrDuplicate {
+			continue
+		} else if err != nil {
+			return
+		}
+		q.prepend(elems[i])
+	}
+}
+
+func (q *Queue[T]) prepend(elem T) int64 {
+	if q.cmp != nil {
+		return q.insert(q.search(elem, true), elem)
 	}
 
-	q.head = (q.head - 1) & (len(q.buf) - 1)
 	id := q.newId()
-	q.items[id] = elem
-	q.ids[elem] = id
-	q.buf[q.head] = id
-	// bitwise modulus
-	q.count++
+	q.store(id, elem)
+	q.pushFront(slot[T]{id, elem})
+	q.added(elem)
 
 	q.notify()
 
 	if q.count == 1 {
 		q.notEmpty.Broadcast()
 	}
+	return id
 }
 
 // Previews element at the front of queue
 func (q *Queue[T]) Front() T {
-	var result T
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
 
-	id := q.buf[q.head]
-	if id != 0 {
-		result = q.items[id]
-	}
-	return result
+	return q.buf[q.head].elem
 }
 
 // Previews element at the back of queue
 func (q *Queue[T]) Back() T {
-	var result T
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	id := q.buf[(q.tail-1)&(len(q.buf)-1)]
-	if id != 0 {
-		result = q.items[id]
-	}
-	return result
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	return q.buf[(q.tail-1)&(len(q.buf)-1)].elem
 }
 
-func (q *Queue[T]) pop() int64 {
+// pop waits until the buffer has a slot and removes it with popSlot
+func (q *Queue[T]) pop(ctx context.Context, popSlot func() slot[T]) (slot[T], error) {
 	for {
 		if q.count <= 0 {
-			q.notEmpty.Wait()
+			if q.closed {
+				return slot[T]{}, ErrClosed
+			}
+			start := q.waitStart()
+			err := waitContext(ctx, q.notEmpty)
+			q.waited(start, "queue: waited long for an element")
+			if err != nil {
+				return slot[T]{}, err
+			}
 		}
 
 		// I have no idea why, but sometimes it's less than 0
@@ -180,83 +464,270 @@ func (q *Queue[T]) pop() int64 {
 		}
 	}
 
-	id := q.buf[q.head]
-	q.buf[q.head] = 0
+	return popSlot(), nil
+}
+
+// pushBack adds s at the back of the buffer
+func (q *Queue[T]) pushBack(s slot[T]) {
+	q.thaw()
+	if q.count == len(q.buf) {
+		q.resize()
+	}
+
+	q.buf[q.tail] = s
+	// bitwise modulus
+	q.tail = (q.tail + 1) & (len(q.buf) - 1)
+	q.count++
+}
+
+// pushFront adds s at the front of the buffer
+func (q *Queue[T]) pushFront(s slot[T]) {
+	q.thaw()
+	if q.count == len(q.buf) {
+		q.resize()
+	}
+
+	// bitwise modulus
+	q.head = (q.head - 1) & (len(q.buf) - 1)
+	q.buf[q.head] = s
+	q.count++
+}
+
+// popFront removes the slot at the front of the buffer and returns it
+func (q *Queue[T]) popFront() slot[T] {
+	q.thaw()
+	s := q.buf[q.head]
+	q.buf[q.head] = slot[T]{}
 
 	// bitwise modulus
 	q.head = (q.head + 1) & (len(q.buf) - 1)
 	q.count--
-	if len(q.buf) > minQueueLen && (q.count<<1) == len(q.buf) {
+	q.shrink()
+
+	return s
+}
+
+// shrink resizes the buffer once it is only half full
+func (q *Queue[T]) shrink() {
+	if !q.keepBuffer && len(q.buf) > q.minBuf && (q.count<<1) == len(q.buf) {
 		q.resize()
 	}
+}
+
+// popBack removes the slot at the back of the buffer and returns it
+func (q *Queue[T]) popBack() slot[T] {
+	q.thaw()
+	// bitwise modulus
+	q.tail = (q.tail - 1) & (len(q.buf) - 1)
+	s := q.buf[q.tail]
+	q.buf[q.tail] = slot[T]{}
+	q.count--
+	q.shrink()
+
+	return s
+}
+
+// take removes an element using popSlot, blocking while the queue is empty.
+// It fails with ErrClosed once the queue is closed and empty, or with ctx.Err()
+func (q *Queue[T]) take(ctx context.Context, popSlot func() slot[T]) (T, error) {
+	e, err := q.takeEntry(ctx, popSlot)
+	return e.elem, err
+}
+
+// takeEntry works like take, but also returns the id and metadata of the element
+func (q *Queue[T]) takeEntry(ctx context.Context, popSlot func() slot[T]) (entry[T], error) {
+	s, err := q.pop(ctx, popSlot)
+	if err != nil {
+		return entry[T]{}, err
+	}
+	return q.consume(s), nil
+}
 
-	return id
+// consume does the bookkeeping for s, which was taken out of the buffer by a consumer
+func (q *Queue[T]) consume(s slot[T]) entry[T] {
+	e := q.detach(s)
+	q.delivered(e)
+	return e
+}
+
+// detach does the bookkeeping for s leaving the queue, it is not counted as a pop
+// until delivered is called, so it can still be put back with requeue
+func (q *Queue[T]) detach(s slot[T]) entry[T] {
+	e := entry[T]{id: s.id, elem: s.elem, meta: q.meta[s.id]}
+	q.forget(s.id, s.elem)
+	q.notify()
+	q.removed()
+	return e
+}
+
+// delivered counts e as popped and reports it to the OnPop hook
+func (q *Queue[T]) delivered(e entry[T]) {
+	q.pops++
+	q.observeLatency(e.meta)
+	if q.onPop != nil {
+		q.popped = append(q.popped, e.elem)
+	}
 }
 
 // Pop removes and returns the element from the front of the queue.
-// If the queue is empty, it will block
+// If the queue is empty, it will block. Once the queue is closed and
+// empty it returns the zero value
 func (q *Queue[T]) Pop() T {
 	q.mutex.Lock()
-	defer q.mutex.Unlock()
+	defer q.unlock()
 
-	for {
-		id := q.pop()
+	item, _ := q.take(context.Background(), q.popFront)
+	return item
+}
 
-		item, ok := q.items[id]
+// PopBack removes and returns the element from the back of the queue.
+// If the queue is empty, it will block. Once the queue is closed and
+// empty it returns the zero value
+func (q *Queue[T]) PopBack() T {
+	q.mutex.Lock()
+	defer q.unlock()
 
-		if ok {
-			delete(q.ids, item)
-			delete(q.items, id)
-			q.notify()
-			return item
+	item, _ := q.take(context.Background(), q.popBack)
+	return item
+}
+
+// Take works like Pop, but returns ErrClosed instead of the zero value
+// once the queue is closed and empty
+func (q *Queue[T]) Take() (T, error) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	return q.take(context.Background(), q.popFront)
+}
+
+// PopIf removes and returns the element at the front of the queue if pred returns
+// true for it. It does not block: false is returned if the queue is empty or pred
+// returns false. pred is called while the queue is locked, so the element cannot
+// be popped by someone else in between
+func (q *Queue[T]) PopIf(pred func(T) bool) (T, bool) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if q.count == 0 || !pred(q.buf[q.head].elem) {
+		var zero T
+		return zero, false
+	}
+	elem, err := q.take(context.Background(), q.popFront)
+	return elem, err == nil
+}
+
+// PopWhere removes and returns the element closest to the front for which pred
+// returns true, wherever it is in the queue. It does not block: false is returned
+// if no element matches. pred is called from front to back while the queue is locked
+func (q *Queue[T]) PopWhere(pred func(T) bool) (T, bool) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	found, ok := 0, false
+	q.walk(func(pos int, elem T) bool {
+		if pred(elem) {
+			found, ok = pos, true
+			return false
 		}
+		return true
+	})
+	if !ok {
+		var zero T
+		return zero, false
 	}
+	return q.consume(q.cut(found)).elem, true
 }
 
-// Removes one element from the queue
+// Close wakes up every goroutine blocked in Pop or in Append on a full queue and stops the queue from
+// accepting new elements. Elements already queued can still be popped,
+// after that Pop returns the zero value and Take returns ErrClosed.
+// The NotEmpty channel is closed as well. It always returns nil, the error is
+// there to satisfy Interface
+func (q *Queue[T]) Close() error {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.log(LogInfo, "queue: closed", "length", q.size)
+	close(q.NotEmpty)
+	for _, c := range q.subscribers {
+		close(c)
+	}
+	for _, c := range q.watchers {
+		close(c)
+	}
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.grown.Broadcast()
+	return nil
+}
+
+// Closed reports whether Close has been called
+func (q *Queue[T]) Closed() bool {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	return q.closed
+}
+
+// Removes one element from the queue. If elem is queued more than once only
+// the occurrence closest to the front is removed. The queue is searched from the
+// front, so this takes time proportional to the position of elem.
+// Panics on a queue created by NewAny, use RemoveByHandle there
 func (q *Queue[T]) Remove(elem T) bool {
 	q.mutex.Lock()
-	defer q.mutex.Unlock()
+	defer q.unlock()
 
-	id, ok := q.ids[elem]
+	pos, ok := q.lookup(elem)
 	if !ok {
 		return false
 	}
-	delete(q.ids, elem)
-	delete(q.items, id)
+	q.kill(pos)
+	q.removes++
+	q.removed()
 	return true
 }
 
-func (q *Queue[T]) swapElem(idx1, idx2 int64) {
-	t := q.buf[idx1]
-	q.buf[idx1] = q.buf[idx2]
-	q.buf[idx2] = t
-}
-
-func (q *Queue[T]) partition(s func(elem1 T, elem2 T) int, low, high int64) int64 {
-	pivot := q.items[q.buf[high]]
-	i := low - 1
-	for j := low; j < high; j++ {
-		id := q.buf[j]
-		elem := q.items[id]
-		if s(elem, pivot) <= 0 {
-			i++
-			q.swapElem(i, j)
-		}
+// RemoveAll removes every occurrence of elem and returns how many were removed.
+// Panics on a queue created by NewAny
+func (q *Queue[T]) RemoveAll(elem T) int {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	equal := q.matcher()
+	removed := q.sweep(func(queued T) bool {
+		return equal(queued, elem)
+	})
+	if removed > 0 {
+		q.removes += uint64(removed)
+		q.removed()
 	}
-	q.swapElem(i+1, high)
-	return i + 1
+	return removed
 }
 
-// Sorts the queue
-func (q *Queue[T]) quickSort(s func(elem1 T, elem2 T) int, low, high int64) {
-	if low < high {
-		pi := q.partition(s, low, high)
-		q.quickSort(s, low, pi-1)
-		q.quickSort(s, pi+1, high)
+// RemoveByHandle removes the element identified by h, which was returned by Append or Prepend.
+// It returns false if that element is no longer queued. Finding the element and
+// closing the gap it leaves both take time linear in the length of the queue
+func (q *Queue[T]) RemoveByHandle(h Handle) bool {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	pos, ok := q.find(int64(h))
+	if !ok {
+		return false
 	}
+	q.kill(pos)
+	q.removes++
+	q.removed()
+	return true
 }
 
-func (q *Queue[T]) QuickSort(s func(elem1 T, elem2 T) int) {
-	q.quickSort(s, 0, int64(q.Length())-1)
+func (q *Queue[T]) swapElem(idx1, idx2 int) {
+	q.thaw()
+	t := q.buf[idx1]
+	q.buf[idx1] = q.buf[idx2]
+	q.buf[idx2] = t
 }
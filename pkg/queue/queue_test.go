@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"context"
 	"math/rand"
 	"strings"
 	"sync"
@@ -263,10 +264,10 @@ func TestQueueThreadSafety3(t *testing.T) {
 	wg.Add(10000)
 
 	for i := 0; i < 5000; i++ {
-		go func() {
+		go func(i int) {
 			q.Append(i)
 			wg.Done()
-		}()
+		}(i)
 	}
 
 	for i := 0; i < 5000; i++ {
@@ -325,6 +326,57 @@ func TestQueueBlocking(t *testing.T) {
 	wg.Wait()
 }
 
+func TestQueueCloseWakesPop(t *testing.T) {
+	q := New[int]()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			if _, err := q.Take(); err != ErrClosed {
+				t.Errorf("Take should return ErrClosed, got %v", err)
+			}
+			wg.Done()
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	wg.Wait()
+
+	if q.Pop() != 0 {
+		t.Error("Pop on a closed queue should return the zero value")
+	}
+	if !q.Closed() {
+		t.Error("queue should report closed")
+	}
+}
+
+func TestQueueCloseDrains(t *testing.T) {
+	q := New[int]()
+
+	q.Append(1)
+	q.Append(2)
+	q.Close()
+	q.Append(3)
+
+	if q.Length() != 2 {
+		t.Errorf("Queue length should be 2, it is %d", q.Length())
+	}
+	for _, expected := range []int{1, 2} {
+		item, err := q.Take()
+		if err != nil || item != expected {
+			t.Errorf("There should be %d on take, there is %v (%v)", expected, item, err)
+		}
+	}
+	if _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed, got %v", err)
+	}
+	// a pending notification may still be buffered, ranging ends once it is closed
+	for range q.NotEmpty {
+	}
+}
+
 func assertPanics(t *testing.T, name string, f func()) {
 	defer func() {
 		if r := recover(); r == nil {
@@ -335,6 +387,16 @@ func assertPanics(t *testing.T, name string, f func()) {
 	f()
 }
 
+// assertNoAllocs fails if f allocates, once it has run for warming up. The race
+// detector makes sync.Pool drop objects, so it is not checked with -race
+func assertNoAllocs(t *testing.T, name string, f func()) {
+	t.Helper()
+	f()
+	if allocs := testing.AllocsPerRun(100, f); allocs != 0 && !raceEnabled {
+		t.Errorf("%s should not allocate, it allocated %v times per run", name, allocs)
+	}
+}
+
 func TestFront(t *testing.T) {
 	q := New[int]()
 
@@ -430,6 +492,177 @@ func TestRemove(t *testing.T) {
 	}
 }
 
+func TestRemoveFreesSlots(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 10; i++ {
+		q.Append(i)
+	}
+	q.Remove(0)
+	q.Remove(9)
+	q.Remove(3)
+	q.RemoveFunc(func(elem int) bool { return elem%2 == 0 })
+
+	if q.Front() != 1 || q.Back() != 7 {
+		t.Errorf("Front and Back should be 1 and 7, they are %d and %d", q.Front(), q.Back())
+	}
+	if q.count != q.Length() {
+		t.Errorf("Removed elements should not leave slots behind, %d slots hold %d elements", q.count, q.Length())
+	}
+	if elem, _ := q.PeekAt(2); elem != 7 {
+		t.Errorf("There should be 7 at position 2, there is %d", elem)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	q := New[string]()
+
+	for i := 0; i < 5; i++ {
+		q.Append("a")
+		q.Append("b")
+	}
+	if !q.Remove("a") {
+		t.Error("Remove should find a queued duplicate")
+	}
+	if q.Length() != 9 {
+		t.Errorf("Queue length should be 9, it is %d", q.Length())
+	}
+
+	expected := []string{"b", "a", "b", "a", "b", "a", "b", "a", "b"}
+	for i := range expected {
+		if p := q.Pop(); p != expected[i] {
+			t.Errorf("There should be %s on pop %d, there is %s", expected[i], i, p)
+		}
+	}
+	if q.Remove("a") || q.Remove("b") {
+		t.Error("Remove should not find popped elements")
+	}
+}
+
+func TestDuplicatesRemoveAfterPop(t *testing.T) {
+	q := New[int]()
+
+	q.Append(7)
+	q.Append(7)
+	q.Append(7)
+	q.Pop()
+
+	if !q.Remove(7) {
+		t.Error("Remove should find the remaining duplicates")
+	}
+	if q.Length() != 1 {
+		t.Errorf("Queue length should be 1, it is %d", q.Length())
+	}
+	if p := q.Pop(); p != 7 {
+		t.Errorf("There should be 7 on pop, there is %v", p)
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	q := New[int]()
+
+	q.Append(1)
+	q.Append(2)
+	q.Prepend(2)
+	q.Append(3)
+	q.Append(2)
+
+	if removed := q.RemoveAll(2); removed != 3 {
+		t.Errorf("RemoveAll should remove 3 elements, it removed %d", removed)
+	}
+	if removed := q.RemoveAll(2); removed != 0 {
+		t.Errorf("RemoveAll should remove nothing, it removed %d", removed)
+	}
+	if q.Length() != 2 {
+		t.Errorf("Queue length should be 2, it is %d", q.Length())
+	}
+	if p := q.Pop(); p != 1 {
+		t.Errorf("There should be 1 on pop, there is %v", p)
+	}
+	if p := q.Pop(); p != 3 {
+		t.Errorf("There should be 3 on pop, there is %v", p)
+	}
+}
+
+func TestNonComparable(t *testing.T) {
+	q := NewAny[[]int]()
+
+	q.Append([]int{1})
+	h := q.Append([]int{2, 2})
+	q.Append([]int{3, 3, 3})
+
+	if !q.RemoveByHandle(h) {
+		t.Error("RemoveByHandle should remove the queued element")
+	}
+	if q.RemoveByHandle(h) {
+		t.Error("RemoveByHandle should not remove an element twice")
+	}
+	if q.Length() != 2 {
+		t.Errorf("Queue length should be 2, it is %d", q.Length())
+	}
+	if p := q.Pop(); len(p) != 1 {
+		t.Errorf("There should be [1] on pop, there is %v", p)
+	}
+	if p := q.Pop(); len(p) != 3 {
+		t.Errorf("There should be [3 3 3] on pop, there is %v", p)
+	}
+
+	assertPanics(t, "Remove", func() {
+		q.Remove([]int{1})
+	})
+	assertPanics(t, "RemoveAll", func() {
+		q.RemoveAll([]int{1})
+	})
+}
+
+func TestRemoveByHandle(t *testing.T) {
+	q := New[int]()
+
+	q.Append(1)
+	h := q.Append(2)
+	q.Append(3)
+
+	if !q.RemoveByHandle(h) {
+		t.Error("RemoveByHandle should remove the queued element")
+	}
+	if q.Remove(2) {
+		t.Error("Remove should not find the removed element")
+	}
+	if p := q.Pop(); p != 1 {
+		t.Errorf("There should be 1 on pop, there is %v", p)
+	}
+	if p := q.Pop(); p != 3 {
+		t.Errorf("There should be 3 on pop, there is %v", p)
+	}
+}
+
+func TestRemoveByHandleDuplicates(t *testing.T) {
+	q := New[int]()
+
+	handles := make([]Handle, 5)
+	for i := range handles {
+		if i%2 == 0 {
+			handles[i] = q.Append(9)
+		} else {
+			handles[i] = q.Prepend(9)
+		}
+	}
+
+	if !q.RemoveByHandle(handles[2]) {
+		t.Error("RemoveByHandle should remove the queued element")
+	}
+	if q.Length() != 4 {
+		t.Errorf("Queue length should be 4, it is %d", q.Length())
+	}
+	for i, h := range handles {
+		if q.RemoveByHandle(h) != (i != 2) {
+			t.Errorf("RemoveByHandle of handle %d returned the wrong result", i)
+		}
+	}
+	if q.Length() != 0 {
+		t.Errorf("Queue length should be 0, it is %d", q.Length())
+	}
+}
+
 func TestTestQueueClean(t *testing.T) {
 	q := New[int]()
 
@@ -471,6 +704,103 @@ func TestTestQueueClean2(t *testing.T) {
 	}
 }
 
+func TestPopBack(t *testing.T) {
+	q := New[int]()
+
+	q.Append(2)
+	q.Append(3)
+	q.Prepend(1)
+	q.Append(4)
+	q.Remove(4)
+
+	for _, expected := range []int{3, 2, 1} {
+		if p := q.PopBack(); p != expected {
+			t.Errorf("There should be %d on pop back, there is %v", expected, p)
+		}
+	}
+	if q.Length() != 0 {
+		t.Errorf("Queue length should be 0, it is %d", q.Length())
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Append(5)
+	}()
+	if p := q.PopBack(); p != 5 {
+		t.Errorf("There should be 5 on pop back, there is %v", p)
+	}
+
+	q.Close()
+	if p := q.PopBack(); p != 0 {
+		t.Errorf("PopBack on a closed queue should return the zero value, there is %v", p)
+	}
+}
+
+func TestPopBackWrapping(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 100; i++ {
+		q.Append(i)
+	}
+	for i := 0; i < 50; i++ {
+		q.Pop()
+	}
+	for i := 99; i >= 50; i-- {
+		if p := q.PopBack(); p != i {
+			t.Errorf("There should be %d on pop back, there is %v", i, p)
+		}
+	}
+}
+
+func TestPrependAll(t *testing.T) {
+	q := New[int]()
+
+	q.Append(4)
+	q.Append(5)
+	q.PrependAll(1, 2, 3)
+	q.PrependAll()
+
+	for expected := 1; expected <= 5; expected++ {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %d on pop, there is %v", expected, p)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 50; i++ {
+		q.Append(i)
+	}
+	q.Remove(10)
+
+	drained := q.Drain()
+	if len(drained) != 49 {
+		t.Errorf("Drain should return 49 elements, it returned %d", len(drained))
+	}
+	for i, d := range drained {
+		expected := i
+		if i >= 10 {
+			expected++
+		}
+		if d != expected {
+			t.Errorf("There should be %d at %d, there is %v", expected, i, d)
+		}
+	}
+	if q.Length() != 0 {
+		t.Errorf("Queue length should be 0, it is %d", q.Length())
+	}
+	if q.Remove(20) {
+		t.Error("Remove should not find drained elements")
+	}
+
+	q.Append(1)
+	if p := q.Pop(); p != 1 {
+		t.Errorf("There should be 1 on pop, there is %v", p)
+	}
+}
+
 // General warning: Go's benchmark utility (go test -bench .) increases the number of
 // iterations until the benchmarks take a reasonable amount of time to run; memory usage
 // is *NOT* considered. On my machine, these benchmarks hit around ~1GB before they've had
@@ -478,6 +808,7 @@ func TestTestQueueClean2(t *testing.T) {
 
 func BenchmarkQueueSerial(b *testing.B) {
 	q := New[int]()
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		q.Append(i)
@@ -489,8 +820,149 @@ func BenchmarkQueueSerial(b *testing.B) {
 
 func BenchmarkQueueTickTock(b *testing.B) {
 	q := New[int]()
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		q.Append(i)
 		q.Pop()
 	}
 }
+
+func TestAppendPopAllocations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := New[int]()
+	assertNoAllocs(t, "Append and Pop", func() {
+		q.Append(1)
+		q.Pop()
+	})
+	assertNoAllocs(t, "Prepend and PopBack", func() {
+		q.Prepend(1)
+		q.PopBack()
+	})
+	assertNoAllocs(t, "TryAppend and Take", func() {
+		q.TryAppend(1)
+		q.Take()
+	})
+	assertNoAllocs(t, "AppendContext and PopContext", func() {
+		q.AppendContext(ctx, 1)
+		q.PopContext(ctx)
+	})
+
+	resized := New[int]()
+	assertNoAllocs(t, "Append that grows the buffer and Clean that shrinks it", func() {
+		for i := 0; i < 100; i++ {
+			resized.Append(i)
+		}
+		resized.Clean()
+	})
+
+	bounded := NewBoundedWithPolicy[int](1, OverflowDropOldest, WithOnEvict(func(int, EvictReason) {}))
+	assertNoAllocs(t, "Append that evicts", func() {
+		bounded.Append(1)
+	})
+
+	for name, q := range map[string]*Queue[int]{
+		"dedup":      New[int](WithDedup[int]()),
+		"envelopes":  New[int](WithEnvelopes[int]()),
+		"latency":    New[int](WithLatencyHistogram[int]()),
+		"watermarks": New[int](WithWatermarks[int](1, 0, func(int) {}, func(int) {})),
+		"unlocked":   New[int](WithoutLocking[int]()),
+	} {
+		assertNoAllocs(t, "Append and Pop on a "+name+" queue", func() {
+			q.Append(1)
+			q.Pop()
+		})
+	}
+}
+
+func TestConcurrentReads(t *testing.T) {
+	q := New[int]()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if n := q.Length(); n < 0 || n > 1000 {
+					t.Errorf("length out of bounds: %d", n)
+					return
+				}
+				q.Front()
+				q.Back()
+				q.PeekAt(0)
+			}
+		}()
+	}
+
+	for i := 0; i < 1000; i++ {
+		q.Append(i)
+	}
+	for i := 0; i < 1000; i++ {
+		if elem := q.Pop(); elem != i {
+			t.Fatalf("expected %d, got %d", i, elem)
+		}
+	}
+	close(done)
+	wg.Wait()
+	if q.Length() != 0 {
+		t.Errorf("expected an empty queue, length is %d", q.Length())
+	}
+}
+
+func BenchmarkQueueLengthParallel(b *testing.B) {
+	q := New[int]()
+	q.Append(1)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Length()
+			q.Front()
+		}
+	})
+}
+
+func TestPopIf(t *testing.T) {
+	q := New[int]()
+	if _, ok := q.PopIf(func(int) bool { return true }); ok {
+		t.Error("PopIf on an empty queue should return false")
+	}
+
+	q.Append(1)
+	q.Append(2)
+	if _, ok := q.PopIf(func(elem int) bool { return elem == 2 }); ok {
+		t.Error("PopIf should not pop 1")
+	}
+	if elem, ok := q.PopIf(func(elem int) bool { return elem == 1 }); !ok || elem != 1 {
+		t.Errorf("PopIf should pop 1, it popped %d", elem)
+	}
+	if q.Length() != 1 || q.Front() != 2 {
+		t.Errorf("Queue should hold 2, it holds %v", q.ToSlice())
+	}
+}
+
+func TestPopWhere(t *testing.T) {
+	popped := 0
+	q := New[int](WithOnPop(func(int) { popped++ }))
+	for i := 1; i <= 5; i++ {
+		q.Append(i)
+	}
+
+	if elem, ok := q.PopWhere(func(elem int) bool { return elem%2 == 0 }); !ok || elem != 2 {
+		t.Errorf("PopWhere should pop 2, it popped %d", elem)
+	}
+	if _, ok := q.PopWhere(func(elem int) bool { return elem > 5 }); ok {
+		t.Error("PopWhere should return false if nothing matches")
+	}
+	if s := q.ToSlice(); len(s) != 4 || s[0] != 1 || s[1] != 3 {
+		t.Errorf("Queue should hold 1 3 4 5, it holds %v", s)
+	}
+	if popped != 1 || q.Stats().Pops != 1 {
+		t.Errorf("PopWhere should count as one pop, there were %d", popped)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package queue
+
+// raceEnabled is set when the tests run with the race detector, which makes
+// sync.Pool drop objects at random and so breaks the allocation tests
+const raceEnabled = true
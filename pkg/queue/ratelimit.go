@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimited paces the pops of a queue with a token bucket: it allows perSecond
+// pops per second on average and bursts of up to burst pops after a quiet period.
+// Consumers that pop through it wait for their turn, so the queue itself keeps a
+// client of a rate limited service within its limit. Pops made on the queue
+// directly are not limited. The time is read from the clock of the queue, see WithClock
+type RateLimited[T any] struct {
+	q         *Queue[T]
+	perSecond float64
+	burst     float64
+	mutex     sync.Mutex
+	// tokens left at last, negative when pops have reserved tokens ahead of time
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimited limits the pops of q to perSecond per second with bursts of up
+// to burst pops. The bucket starts full. Panics if perSecond or burst is not positive
+func NewRateLimited[T any](q *Queue[T], perSecond float64, burst int) *RateLimited[T] {
+	if perSecond <= 0 || burst <= 0 {
+		panic("queue: rate and burst must be positive")
+	}
+	return &RateLimited[T]{q: q, perSecond: perSecond, burst: float64(burst), tokens: float64(burst), last: q.clock.Now()}
+}
+
+// Queue returns the queue whose pops are limited
+func (r *RateLimited[T]) Queue() *Queue[T] {
+	return r.q
+}
+
+// refill adds the tokens that accumulated since the last call, the mutex must be held
+func (r *RateLimited[T]) refill() {
+	now := r.q.clock.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.perSecond
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (r *RateLimited[T]) reserve() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.refill()
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.perSecond * float64(time.Second))
+}
+
+// refund gives back a token that was not used for a pop
+func (r *RateLimited[T]) refund() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.refill()
+	r.tokens++
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+}
+
+// PopContext waits for its turn and then pops like Queue.PopContext. It returns
+// ctx.Err() when ctx is done first, the turn is then given back
+func (r *RateLimited[T]) PopContext(ctx context.Context) (T, error) {
+	if d := r.reserve(); d > 0 {
+		select {
+		case <-r.q.clock.After(d):
+		case <-ctx.Done():
+			r.refund()
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+	elem, err := r.q.PopContext(ctx)
+	if err != nil {
+		r.refund()
+	}
+	return elem, err
+}
+
+// Take works like PopContext without a deadline
+func (r *RateLimited[T]) Take() (T, error) {
+	return r.PopContext(context.Background())
+}
+
+// Pop works like Take, but returns the zero value once the queue is closed and empty
+func (r *RateLimited[T]) Pop() T {
+	elem, _ := r.Take()
+	return elem
+}
+
+// TryPop pops without waiting, it returns false if it is not the turn of a pop
+// yet or the queue is empty
+func (r *RateLimited[T]) TryPop() (T, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.refill()
+	if r.tokens < 1 {
+		var zero T
+		return zero, false
+	}
+	elem, ok := r.q.tryTake()
+	if ok {
+		r.tokens--
+	}
+	return elem, ok
+}
@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimited(t *testing.T) {
+	clock := newFakeClock()
+	q := New[int](WithClock[int](clock))
+	for i := 0; i < 5; i++ {
+		q.Append(i)
+	}
+	r := NewRateLimited(q, 10, 2)
+
+	for i := 0; i < 2; i++ {
+		if elem, ok := r.TryPop(); !ok || elem != i {
+			t.Errorf("The burst should allow popping %d, got %d", i, elem)
+		}
+	}
+	if _, ok := r.TryPop(); ok {
+		t.Error("TryPop should fail once the burst is used up")
+	}
+
+	popped := make(chan int)
+	go func() {
+		popped <- r.Pop()
+	}()
+	clock.waitForWaiters(1)
+	select {
+	case elem := <-popped:
+		t.Errorf("Pop should wait for its turn, it returned %d", elem)
+	default:
+	}
+	clock.Advance(100 * time.Millisecond)
+	if elem := <-popped; elem != 2 {
+		t.Errorf("There should be 2 on pop, there is %d", elem)
+	}
+
+	clock.Advance(time.Second)
+	for i := 3; i < 5; i++ {
+		if elem, ok := r.TryPop(); !ok || elem != i {
+			t.Errorf("The refilled burst should allow popping %d, got %d", i, elem)
+		}
+	}
+
+	assertPanics(t, "NewRateLimited", func() {
+		NewRateLimited(q, 0, 1)
+	})
+}
+
+func TestRateLimitedCancel(t *testing.T) {
+	clock := newFakeClock()
+	q := New[int](WithClock[int](clock))
+	q.Append(1)
+	r := NewRateLimited(q, 1, 1)
+	r.Pop()
+	q.Append(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.PopContext(ctx); err != context.Canceled {
+		t.Errorf("PopContext should return the error of ctx, got %v", err)
+	}
+	// the turn of the cancelled pop is given back
+	clock.Advance(time.Second)
+	if elem, ok := r.TryPop(); !ok || elem != 2 {
+		t.Errorf("There should be 2 on pop a second later, there is %d", elem)
+	}
+}
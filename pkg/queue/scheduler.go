@@ -0,0 +1,156 @@
+package queue
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// Scheduler pops from a set of member queues in proportion to their weights, so
+// that with weights 70, 20 and 10 the members get 70%, 20% and 10% of the pops
+// while all of them have elements. A member without elements is skipped and its
+// share goes to the others. The pops of the members are interleaved rather than
+// served in runs. Members stay usable on their own, the Scheduler only pops from them
+type Scheduler[T any] struct {
+	members []*member[T]
+	mutex   *sync.Mutex
+	// closed and replaced when the members change, to wake up every waiting pop
+	changed chan struct{}
+	closed  bool
+}
+
+type member[T any] struct {
+	q      *Queue[T]
+	weight int
+	// smooth weighted round robin counter, the member with the highest is served next
+	current int
+}
+
+// NewScheduler creates a Scheduler without members
+func NewScheduler[T any]() *Scheduler[T] {
+	return &Scheduler[T]{mutex: &sync.Mutex{}, changed: make(chan struct{})}
+}
+
+// Add makes q a member with the given weight. Panics if weight is not positive
+func (s *Scheduler[T]) Add(q *Queue[T], weight int) {
+	if weight <= 0 {
+		panic("queue: weight must be positive")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.members = append(s.members, &member[T]{q: q, weight: weight})
+	if !s.closed {
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+}
+
+// tryPop pops from the member that is due, it returns false if none has elements.
+// The mutex must be held
+func (s *Scheduler[T]) tryPop() (T, bool) {
+	for {
+		var next *member[T]
+		total := 0
+		for _, m := range s.members {
+			if m.q.Length() == 0 {
+				continue
+			}
+			m.current += m.weight
+			total += m.weight
+			if next == nil || m.current > next.current {
+				next = m
+			}
+		}
+		if next == nil {
+			var zero T
+			return zero, false
+		}
+		next.current -= total
+		// another consumer of the member may have emptied it in the meantime
+		if elem, ok := next.q.tryTake(); ok {
+			return elem, true
+		}
+	}
+}
+
+// TryPop removes and returns an element from the member that is due without
+// blocking. It returns false if no member has elements
+func (s *Scheduler[T]) TryPop() (T, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.tryPop()
+}
+
+// PopContext removes and returns an element from the member that is due. If no
+// member has elements it blocks until one has, returning ctx.Err() when ctx is
+// done first, or ErrClosed once the Scheduler or every member is closed and empty
+func (s *Scheduler[T]) PopContext(ctx context.Context) (T, error) {
+	for {
+		s.mutex.Lock()
+		if elem, ok := s.tryPop(); ok {
+			s.mutex.Unlock()
+			return elem, nil
+		}
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.changed)},
+		}
+		// every call waits on subscriptions of its own, so a signal taken by
+		// one consumer cannot leave another one asleep while elements are queued.
+		// A subscription is signalled right away if its member has elements
+		var subscribed []*member[T]
+		var signals []<-chan struct{}
+		for _, m := range s.members {
+			if m.q.Closed() {
+				continue
+			}
+			c := m.q.Subscribe()
+			subscribed = append(subscribed, m)
+			signals = append(signals, c)
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)})
+		}
+		closed := s.closed || (len(s.members) > 0 && len(subscribed) == 0)
+		s.mutex.Unlock()
+
+		if closed {
+			var zero T
+			return zero, ErrClosed
+		}
+		chosen, _, _ := reflect.Select(cases)
+		for i, m := range subscribed {
+			m.q.Unsubscribe(signals[i])
+		}
+		if chosen == 0 {
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Take works like PopContext without a deadline
+func (s *Scheduler[T]) Take() (T, error) {
+	return s.PopContext(context.Background())
+}
+
+// Pop works like Take, but returns the zero value instead of ErrClosed
+func (s *Scheduler[T]) Pop() T {
+	elem, _ := s.Take()
+	return elem
+}
+
+// Close wakes up every goroutine blocked in Pop, which then returns ErrClosed.
+// The members are not closed
+func (s *Scheduler[T]) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.changed)
+	return nil
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	a, b, c := New[string](), New[string](), New[string]()
+	for i := 0; i < 100; i++ {
+		a.Append("a")
+		b.Append("b")
+		c.Append("c")
+	}
+	s := NewScheduler[string]()
+	s.Add(a, 7)
+	s.Add(b, 2)
+	s.Add(c, 1)
+
+	counts := map[string]int{}
+	for i := 0; i < 10; i++ {
+		counts[s.Pop()]++
+	}
+	if counts["a"] != 7 || counts["b"] != 2 || counts["c"] != 1 {
+		t.Errorf("Members should get 7, 2 and 1 pops, they got %v", counts)
+	}
+
+	// an empty member passes its share on
+	a.Clean()
+	b.Clean()
+	for i := 0; i < 5; i++ {
+		if elem, ok := s.TryPop(); !ok || elem != "c" {
+			t.Fatalf("There should be c on pop, there is %s", elem)
+		}
+	}
+
+	assertPanics(t, "Add", func() {
+		s.Add(a, 0)
+	})
+}
+
+func TestSchedulerBlocking(t *testing.T) {
+	a, b := New[int](), New[int]()
+	s := NewScheduler[int]()
+	s.Add(a, 1)
+	s.Add(b, 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Append(2)
+	}()
+	if elem := s.Pop(); elem != 2 {
+		t.Errorf("Pop should wait for 2, it returned %d", elem)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("PopContext should time out, got %v", err)
+	}
+
+	a.Close()
+	b.Close()
+	if _, err := s.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed once every member is closed, got %v", err)
+	}
+}
+
+func TestSchedulerClose(t *testing.T) {
+	s := NewScheduler[int]()
+	s.Add(New[int](), 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Close()
+	}()
+	if _, err := s.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed once the scheduler is closed, got %v", err)
+	}
+}
+
+func TestSchedulerConcurrentConsumers(t *testing.T) {
+	q := New[int]()
+	s := NewScheduler[int]()
+	s.Add(q, 1)
+
+	popped := make(chan int)
+	for i := 0; i < 2; i++ {
+		go func() {
+			popped <- s.Pop()
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	q.Append(1)
+	q.Append(2)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-popped:
+		case <-time.After(time.Second):
+			t.Fatal("Both consumers should wake up for the two elements")
+		}
+	}
+}
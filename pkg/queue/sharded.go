@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedQueue spreads its elements over several queues, each with its own lock,
+// so that many producers and consumers rarely contend for the same mutex.
+// Appends go to the shards in turn and pops take from them in turn, so elements
+// come out in roughly, but not exactly, the order they were appended
+type ShardedQueue[T any] struct {
+	shards []*Queue[T]
+	// round robin positions of Append and Pop
+	next, pop uint64
+	// number of consumers waiting for an element
+	waiting int32
+	mutex   *sync.Mutex
+	ready   *sync.Cond
+	closed  bool
+}
+
+// NewSharded creates a queue with the given number of shards, opts apply to every
+// shard. Panics if shards is not positive
+func NewSharded[T any](shards int, opts ...Option[T]) *ShardedQueue[T] {
+	if shards <= 0 {
+		panic("queue: number of shards must be positive")
+	}
+	s := &ShardedQueue[T]{mutex: &sync.Mutex{}}
+	s.ready = sync.NewCond(s.mutex)
+	for i := 0; i < shards; i++ {
+		s.shards = append(s.shards, NewAny[T](opts...))
+	}
+	return s
+}
+
+// Returns the number of elements in queue, counted without locking the shards.
+// It is the sum of the shard lengths, so it accounts for elements the shards
+// drop or merge on their own, such as with OverflowDropOldest or WithCoalesce
+func (s *ShardedQueue[T]) Length() int {
+	length := 0
+	for _, shard := range s.shards {
+		length += shard.Length()
+	}
+	return length
+}
+
+// Append adds elem to the next shard, it returns false if the queue is closed
+func (s *ShardedQueue[T]) Append(elem T) bool {
+	return s.AppendContext(context.Background(), elem) == nil
+}
+
+// AppendContext adds elem to the next shard like Queue.AppendContext
+func (s *ShardedQueue[T]) AppendContext(ctx context.Context, elem T) error {
+	shard := s.shards[atomic.AddUint64(&s.next, 1)%uint64(len(s.shards))]
+	if err := shard.AppendContext(ctx, elem); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&s.waiting) > 0 {
+		s.mutex.Lock()
+		s.ready.Broadcast()
+		s.mutex.Unlock()
+	}
+	return nil
+}
+
+// TryPop removes and returns an element without blocking, it returns false if every shard is empty
+func (s *ShardedQueue[T]) TryPop() (T, bool) {
+	start := atomic.AddUint64(&s.pop, 1)
+	for i := range s.shards {
+		shard := s.shards[(start+uint64(i))%uint64(len(s.shards))]
+		if elem, ok := shard.tryTake(); ok {
+			return elem, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Take removes and returns an element, blocking while every shard is empty.
+// It returns ErrClosed once the queue is closed and empty
+func (s *ShardedQueue[T]) Take() (T, error) {
+	return s.PopContext(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (s *ShardedQueue[T]) PopContext(ctx context.Context) (T, error) {
+	for {
+		if elem, ok := s.TryPop(); ok {
+			return elem, nil
+		}
+
+		s.mutex.Lock()
+		// announce the wait before checking the length, an Append that increments
+		// the length after the check is then sure to wake this consumer
+		atomic.AddInt32(&s.waiting, 1)
+		var err error
+		for s.Length() == 0 && !s.closed && err == nil {
+			err = waitContext(ctx, s.ready)
+		}
+		atomic.AddInt32(&s.waiting, -1)
+		closed := s.closed
+		s.mutex.Unlock()
+
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		if closed && s.Length() == 0 {
+			var zero T
+			return zero, ErrClosed
+		}
+	}
+}
+
+// Close closes every shard and wakes up the waiting consumers. Elements already
+// queued can still be popped, after that Take returns ErrClosed. It always returns nil
+func (s *ShardedQueue[T]) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	s.ready.Broadcast()
+	s.mutex.Unlock()
+
+	for _, shard := range s.shards {
+		shard.Close()
+	}
+	return nil
+}
+
+// tryTake removes the element at the front of the queue if there is one
+func (q *Queue[T]) tryTake() (T, bool) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if q.size == 0 {
+		var zero T
+		return zero, false
+	}
+	elem, err := q.take(context.Background(), q.popFront)
+	return elem, err == nil
+}
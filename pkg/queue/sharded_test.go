@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedQueue(t *testing.T) {
+	q := NewSharded[int](4)
+	const producers, perProducer = 8, 1000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.Append(p*perProducer + i)
+			}
+		}(p)
+	}
+
+	results := make(chan []int)
+	for c := 0; c < 4; c++ {
+		go func() {
+			var got []int
+			for {
+				elem, err := q.Take()
+				if err != nil {
+					results <- got
+					return
+				}
+				got = append(got, elem)
+			}
+		}()
+	}
+
+	wg.Wait()
+	q.Close()
+	var all []int
+	for c := 0; c < 4; c++ {
+		all = append(all, <-results...)
+	}
+	sort.Ints(all)
+	if len(all) != producers*perProducer {
+		t.Fatalf("expected %d elements, got %d", producers*perProducer, len(all))
+	}
+	for i, elem := range all {
+		if elem != i {
+			t.Fatalf("element %d is missing or duplicated", i)
+		}
+	}
+	if q.Length() != 0 {
+		t.Errorf("expected an empty queue, length is %d", q.Length())
+	}
+	if q.Append(1) {
+		t.Error("Append should fail on a closed queue")
+	}
+}
+
+func TestShardedQueuePopContext(t *testing.T) {
+	q := NewSharded[int](2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to pass, got %v", err)
+	}
+
+	done := make(chan int)
+	go func() {
+		elem, _ := q.Take()
+		done <- elem
+	}()
+	q.Append(7)
+	if elem := <-done; elem != 7 {
+		t.Errorf("expected 7, got %d", elem)
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop should fail on an empty queue")
+	}
+}
+
+func TestShardedDroppingShards(t *testing.T) {
+	s := NewSharded(2, WithBound[int](1), WithOverflowPolicy[int](OverflowDropOldest))
+	for i := 0; i < 6; i++ {
+		s.Append(i)
+	}
+	if s.Length() != 2 {
+		t.Errorf("Length should count the 2 elements the shards kept, it is %d", s.Length())
+	}
+	s.TryPop()
+	s.TryPop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("PopContext should wait on the empty queue, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package queue
+
+// Sizer returns the size of elem in bytes, as counted against the limit set by WithMaxBytes
+type Sizer[T any] func(elem T) int
+
+// Sized is implemented by elements that know their own size in bytes
+type Sized interface {
+	Size() int
+}
+
+// WithMaxBytes bounds the queue by the total size of its elements instead of, or
+// on top of, their number. sizer measures every element; if it is nil the
+// elements must implement Sized, or adding one panics. An element that does not
+// fit applies the OverflowPolicy like on a queue that is full, but an empty queue
+// always accepts an element, however large, so that it cannot block forever.
+// Panics if limit is not positive
+func WithMaxBytes[T any](limit int, sizer Sizer[T]) Option[T] {
+	if limit <= 0 {
+		panic("queue: byte limit must be positive")
+	}
+	if sizer == nil {
+		sizer = func(elem T) int {
+			return any(elem).(Sized).Size()
+		}
+	}
+	return func(s *settings[T]) {
+		s.maxBytes = limit
+		s.sizer = sizer
+	}
+}
+
+// Bytes returns the total size of the queued elements as measured by the Sizer
+// given to WithMaxBytes, or 0 if the queue has none
+func (q *Queue[T]) Bytes() int {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	return q.bytes
+}
+
+// fits reports whether elem can be added without going over the capacity or the byte limit
+func (q *Queue[T]) fits(elem T) bool {
+	if q.capacity > 0 && q.size >= q.capacity {
+		return false
+	}
+	return q.maxBytes == 0 || q.size == 0 || q.bytes+q.sizer(elem) <= q.maxBytes
+}
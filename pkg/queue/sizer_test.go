@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type blob []byte
+
+func (b blob) Size() int {
+	return len(b)
+}
+
+func TestWithMaxBytes(t *testing.T) {
+	q := New[string](WithMaxBytes[string](10, func(s string) int { return len(s) }), WithOverflowPolicy[string](OverflowReject))
+
+	for _, s := range []string{"abcd", "efgh"} {
+		if !q.TryAppend(s) {
+			t.Fatalf("%s should fit in the byte limit", s)
+		}
+	}
+	if q.TryAppend("ijk") {
+		t.Error("ijk should not fit in the byte limit")
+	}
+	if !q.TryAppend("ij") {
+		t.Error("ij should fit in the byte limit")
+	}
+	if q.Bytes() != 10 || !q.Full() {
+		t.Errorf("Queue should be full with 10 bytes, it has %d", q.Bytes())
+	}
+
+	q.Pop()
+	if q.Bytes() != 6 {
+		t.Errorf("Queue should have 6 bytes after a pop, it has %d", q.Bytes())
+	}
+}
+
+func TestWithMaxBytesBlock(t *testing.T) {
+	q := NewAny[blob](WithMaxBytes[blob](4, nil))
+	q.Append(blob("abc"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.AppendContext(ctx, blob("de")); err != context.DeadlineExceeded {
+		t.Errorf("Append should block while the bytes do not fit, got %v", err)
+	}
+
+	q.Pop()
+	if err := q.AppendContext(context.Background(), blob("too large")); err != nil {
+		t.Errorf("An empty queue should accept an element over the limit, got %v", err)
+	}
+}
+
+func TestWithMaxBytesDropOldest(t *testing.T) {
+	q := New[string](WithMaxBytes[string](6, func(s string) int { return len(s) }), WithOverflowPolicy[string](OverflowDropOldest))
+	q.Append("ab")
+	q.Append("cd")
+	q.Append("ef")
+	q.Append("ghij")
+
+	if s := q.ToSlice(); len(s) != 2 || s[0] != "ef" || q.Bytes() != 6 {
+		t.Errorf("Queue should hold ef and ghij, it holds %v", s)
+	}
+}
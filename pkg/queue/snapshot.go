@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// Snapshot writes the elements of the queue to w, encoded with the codec set by
+// WithCodec. Reserved elements that have not been acked come first, as they would
+// be put back at the front, in the order they were reserved, followed by the
+// queued elements from front to back
+func (q *Queue[T]) Snapshot(w io.Writer) error {
+	q.mutex.Lock()
+	reserved := make([]*reservation[T], 0, len(q.reserved))
+	for _, r := range q.reserved {
+		reserved = append(reserved, r)
+	}
+	sort.Slice(reserved, func(i, j int) bool {
+		return reserved[i].seq < reserved[j].seq
+	})
+	elems := make([]T, 0, len(reserved)+q.size)
+	for _, r := range reserved {
+		elems = append(elems, r.elem)
+	}
+	q.walk(func(_ int, elem T) bool {
+		elems = append(elems, elem)
+		return true
+	})
+	q.mutex.Unlock()
+
+	bw := bufio.NewWriter(w)
+	if err := writeUvarint(bw, uint64(len(elems))); err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		data, err := q.codec.Encode(elem)
+		if err != nil {
+			return err
+		}
+		if err := writeUvarint(bw, uint64(len(data))); err != nil {
+			return err
+		}
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Restore replaces the elements of the queue with the ones Snapshot wrote to r.
+// The capacity of a bounded queue is not enforced. If r cannot be read or decoded
+// the queue is left unchanged. Sizes in the input are not trusted, an element is
+// only read as far as the input goes
+func (q *Queue[T]) Restore(r io.Reader) error {
+	br := bufio.NewReader(r)
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return err
+	}
+	var elems []T
+	for i := uint64(0); i < n; i++ {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		// a corrupt size must not allocate more than the input holds
+		data, err := io.ReadAll(io.LimitReader(br, int64(size)))
+		if err != nil {
+			return err
+		}
+		if size > uint64(len(data)) {
+			return io.ErrUnexpectedEOF
+		}
+		elem, err := q.codec.Decode(data)
+		if err != nil {
+			return err
+		}
+		elems = append(elems, elem)
+	}
+
+	return q.load(elems)
+}
+
+// load replaces the elements of the queue with elems. A zero Queue, such as one
+// allocated by a decoder, is set up like NewAny first
+func (q *Queue[T]) load(elems []T) error {
+	if q.mutex == nil {
+		*q = *newQueue[T](nil, nil)
+	}
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	q.reset()
+	for _, elem := range elems {
+		q.append(elem)
+	}
+	return nil
+}
+
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	_, err := w.Write(buf[:binary.PutUvarint(buf[:], v)])
+	return err
+}
+
+// unexpectedEOF turns io.EOF into io.ErrUnexpectedEOF, for reads that cannot end the input
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	q := New[string]()
+	for _, s := range []string{"a", "b", "c", "d"} {
+		q.Append(s)
+	}
+	q.Remove("b")
+	_, _, _ = q.Reserve()
+
+	var buf bytes.Buffer
+	if err := q.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot should succeed, got %v", err)
+	}
+
+	restored := New[string]()
+	restored.Append("stale")
+	if err := restored.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Restore should succeed, got %v", err)
+	}
+	if s := restored.ToSlice(); !reflect.DeepEqual(s, []string{"a", "c", "d"}) {
+		t.Errorf("Restored queue should be [a c d], it is %v", s)
+	}
+
+	truncated := New[string]()
+	truncated.Append("kept")
+	if err := truncated.Restore(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err != io.ErrUnexpectedEOF {
+		t.Errorf("Restore of a truncated snapshot should return io.ErrUnexpectedEOF, got %v", err)
+	}
+	if truncated.Length() != 1 {
+		t.Errorf("A failed restore should leave the queue unchanged, length is %d", truncated.Length())
+	}
+}
+
+func TestSnapshotReservationOrder(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 20; i++ {
+		q.Append(i)
+	}
+	for i := 0; i < 10; i++ {
+		q.Reserve()
+	}
+
+	var buf bytes.Buffer
+	if err := q.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot should succeed, got %v", err)
+	}
+	restored := New[int]()
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("Restore should succeed, got %v", err)
+	}
+	for i, item := range restored.ToSlice() {
+		if item != i {
+			t.Fatalf("Reserved elements should come first in reservation order, got %v", restored.ToSlice())
+		}
+	}
+}
+
+func TestRestoreOversizedElement(t *testing.T) {
+	var buf bytes.Buffer
+	writeUvarint(&buf, 1)
+	writeUvarint(&buf, 1<<62)
+	buf.WriteString("short")
+
+	q := New[string]()
+	if err := q.Restore(&buf); err != io.ErrUnexpectedEOF {
+		t.Errorf("Restore of an element larger than the input should return io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package queue
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// liveSlots returns the slots of all queued elements from front to back
+func (q *Queue[T]) liveSlots() []slot[T] {
+	slots := make([]slot[T], 0, q.size)
+	q.walk(func(pos int, _ T) bool {
+		slots = append(slots, q.buf[pos])
+		return true
+	})
+	return slots
+}
+
+// rebuild lays the buffer out again so that it holds exactly slots, front to back.
+// The buffer does not shrink below the initial capacity, nor at all with WithoutShrinking
+func (q *Queue[T]) rebuild(slots []slot[T]) {
+	size := bufferSize(len(slots))
+	if size < q.minBuf {
+		size = q.minBuf
+	}
+	if q.keepBuffer && size < len(q.buf) {
+		size = len(q.buf)
+	}
+
+	if size == len(q.buf) && !q.frozen {
+		// slots is a copy, so the buffer can be written over in place
+		n := copy(q.buf, slots)
+		var zero slot[T]
+		for i := n; i < len(q.buf); i++ {
+			q.buf[i] = zero
+		}
+	} else {
+		buf := q.buffer(size)
+		copy(buf, slots)
+		q.recycle(q.buf)
+		q.frozen = false
+		q.buf = buf
+	}
+	q.head = 0
+	q.tail = len(slots) & (size - 1)
+	q.count = len(slots)
+}
+
+// selection is a max-heap of positions in slots, used to find the k smallest elements
+type selection[T any] struct {
+	slots []slot[T]
+	top   []int
+	cmp   func(elem1 T, elem2 T) int
+}
+
+// before orders positions by their element and by position for equal elements
+func (s *selection[T]) before(a, b int) bool {
+	if c := s.cmp(s.slots[a].elem, s.slots[b].elem); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+func (s *selection[T]) Len() int           { return len(s.top) }
+func (s *selection[T]) Less(i, j int) bool { return s.before(s.top[j], s.top[i]) }
+func (s *selection[T]) Swap(i, j int)      { s.top[i], s.top[j] = s.top[j], s.top[i] }
+func (s *selection[T]) Push(x any)         { s.top = append(s.top, x.(int)) }
+
+func (s *selection[T]) Pop() any {
+	n := len(s.top) - 1
+	x := s.top[n]
+	s.top = s.top[:n]
+	return x
+}
+
+// PartialSort moves the k smallest elements according to cmp to the front of the
+// queue, in sorted order. Elements that are equal keep their relative order, as
+// do the remaining elements behind the first k. This costs O(n log k) instead of
+// a full sort. cmp works like the comparator of QuickSort
+func (q *Queue[T]) PartialSort(k int, cmp func(elem1 T, elem2 T) int) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	if k <= 0 {
+		return
+	}
+	slots := q.liveSlots()
+	if k > len(slots) {
+		k = len(slots)
+	}
+
+	s := &selection[T]{slots: slots, top: make([]int, 0, k), cmp: cmp}
+	for i := range slots {
+		if len(s.top) < k {
+			heap.Push(s, i)
+		} else if s.before(i, s.top[0]) {
+			s.top[0] = i
+			heap.Fix(s, 0)
+		}
+	}
+
+	sort.Slice(s.top, func(i, j int) bool {
+		return s.before(s.top[i], s.top[j])
+	})
+	selected := make(map[int]bool, k)
+	sorted := make([]slot[T], 0, len(slots))
+	for _, i := range s.top {
+		selected[i] = true
+		sorted = append(sorted, slots[i])
+	}
+	for i, sl := range slots {
+		if !selected[i] {
+			sorted = append(sorted, sl)
+		}
+	}
+	q.rebuild(sorted)
+}
+
+// QuickSort sorts the queue according to s, which returns a negative number
+// when elem1 goes before elem2, zero when they are equal and a positive number
+// otherwise. Despite its name it uses introsort, see introSort
+func (q *Queue[T]) QuickSort(s func(elem1 T, elem2 T) int) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	slots := q.liveSlots()
+	introSort(slots, func(a, b slot[T]) bool {
+		return s(a.elem, b.elem) < 0
+	})
+	q.rebuild(slots)
+}
+
+// StableSort sorts the queue like QuickSort, but elements that are equal according
+// to s keep their relative order, for example jobs of the same priority stay in
+// submission order
+func (q *Queue[T]) StableSort(s func(elem1 T, elem2 T) int) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	slots := q.liveSlots()
+	mergeSort(slots, func(a, b slot[T]) bool {
+		return s(a.elem, b.elem) < 0
+	})
+	q.rebuild(slots)
+}
+
+// mergeSort is a stable bottom-up merge sort: runs of insertionSortLen elements
+// are insertion sorted, then merged pairwise into runs of doubling length
+func mergeSort[E any](data []E, less func(a, b E) bool) {
+	n := len(data)
+	for lo := 0; lo < n; lo += insertionSortLen {
+		hi := lo + insertionSortLen
+		if hi > n {
+			hi = n
+		}
+		insertionSort(data[lo:hi], less)
+	}
+
+	src, dst := data, make([]E, n)
+	for width := insertionSortLen; width < n; width <<= 1 {
+		for lo := 0; lo < n; lo += 2 * width {
+			mid, hi := lo+width, lo+2*width
+			if mid > n {
+				mid = n
+			}
+			if hi > n {
+				hi = n
+			}
+			merge(dst[lo:hi], src[lo:mid], src[mid:hi], less)
+		}
+		src, dst = dst, src
+	}
+	if len(data) > 0 && &src[0] != &data[0] {
+		copy(data, src)
+	}
+}
+
+// merge merges the sorted a and b into dst, taking from a first on ties
+func merge[E any](dst, a, b []E, less func(a, b E) bool) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if less(b[j], a[i]) {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
+
+// insertionSortLen is the length below which ranges are insertion sorted
+const insertionSortLen = 12
+
+// introSort sorts data without recursion. It runs quicksort with median of three
+// pivots and an explicit stack, which always continues with the smaller half so
+// the stack stays O(log n). Short ranges are insertion sorted, and ranges that
+// partition badly too often fall back to heapsort, guaranteeing O(n log n)
+func introSort[E any](data []E, less func(a, b E) bool) {
+	type span struct {
+		lo, hi, depth int
+	}
+
+	depth := 0
+	for n := len(data); n > 0; n >>= 1 {
+		depth += 2
+	}
+	stack := []span{{0, len(data), depth}}
+
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for s.hi-s.lo > insertionSortLen {
+			if s.depth == 0 {
+				heapSort(data[s.lo:s.hi], less)
+				s.lo = s.hi
+				break
+			}
+			s.depth--
+
+			p := partition(data, s.lo, s.hi, less)
+			if p-s.lo < s.hi-p-1 {
+				stack = append(stack, span{p + 1, s.hi, s.depth})
+				s.hi = p
+			} else {
+				stack = append(stack, span{s.lo, p, s.depth})
+				s.lo = p + 1
+			}
+		}
+		insertionSort(data[s.lo:s.hi], less)
+	}
+}
+
+// partition places a median of three pivot at its final position in data[lo:hi] and
+// returns that position. Elements equal to the pivot end up on both sides
+func partition[E any](data []E, lo, hi int, less func(a, b E) bool) int {
+	mid := int(uint(lo+hi) >> 1)
+	if less(data[mid], data[lo]) {
+		data[mid], data[lo] = data[lo], data[mid]
+	}
+	if less(data[hi-1], data[mid]) {
+		data[hi-1], data[mid] = data[mid], data[hi-1]
+		if less(data[mid], data[lo]) {
+			data[mid], data[lo] = data[lo], data[mid]
+		}
+	}
+	data[lo], data[mid] = data[mid], data[lo]
+
+	pivot := data[lo]
+	i, j := lo+1, hi-1
+	for {
+		for i <= j && less(data[i], pivot) {
+			i++
+		}
+		for i <= j && less(pivot, data[j]) {
+			j--
+		}
+		if i >= j {
+			break
+		}
+		data[i], data[j] = data[j], data[i]
+		i++
+		j--
+	}
+	data[lo], data[j] = data[j], data[lo]
+	return j
+}
+
+func insertionSort[E any](data []E, less func(a, b E) bool) {
+	for i := 1; i < len(data); i++ {
+		for j := i; j > 0 && less(data[j], data[j-1]); j-- {
+			data[j], data[j-1] = data[j-1], data[j]
+		}
+	}
+}
+
+func heapSort[E any](data []E, less func(a, b E) bool) {
+	for i := len(data)/2 - 1; i >= 0; i-- {
+		siftDown(data, i, len(data), less)
+	}
+	for end := len(data) - 1; end > 0; end-- {
+		data[0], data[end] = data[end], data[0]
+		siftDown(data, 0, end, less)
+	}
+}
+
+func siftDown[E any](data []E, root, end int, less func(a, b E) bool) {
+	for {
+		child := 2*root + 1
+		if child >= end {
+			return
+		}
+		if child+1 < end && less(data[child], data[child+1]) {
+			child++
+		}
+		if !less(data[root], data[child]) {
+			return
+		}
+		data[root], data[child] = data[child], data[root]
+		root = child
+	}
+}
+
+// Ordered is satisfied by every type that supports the < operator
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// compareOrdered is a comparator for ordered types, it treats NaN as equal to everything
+func compareOrdered[K Ordered](a K, b K) int {
+	switch {
+	case a < b:
+		return -1
+	case b < a:
+		return 1
+	}
+	return 0
+}
+
+// SortOrdered sorts a queue of an ordered type in ascending order
+func SortOrdered[T Ordered](q *Queue[T]) {
+	q.QuickSort(compareOrdered[T])
+}
+
+// SortBy sorts the queue in ascending order of the key that key extracts from
+// every element. Elements with equal keys keep their relative order
+func SortBy[T any, K Ordered](q *Queue[T], key func(T) K) {
+	q.StableSort(func(elem1 T, elem2 T) int {
+		return compareOrdered(key(elem1), key(elem2))
+	})
+}
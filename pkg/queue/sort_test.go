@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPartialSort(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 1000; i++ {
+		q.Append(rand.Intn(100000))
+	}
+	// leave dead slots behind and make the ring wrap
+	for i := 0; i < 10; i++ {
+		q.Remove(q.Front())
+		q.Append(q.Pop())
+	}
+
+	before := q.ToSlice()
+	q.PartialSort(10, func(a int, b int) int { return a - b })
+	after := q.ToSlice()
+
+	if len(after) != len(before) {
+		t.Fatalf("PartialSort should keep %d elements, there are %d", len(before), len(after))
+	}
+	for i := 1; i < 10; i++ {
+		if after[i] < after[i-1] {
+			t.Errorf("first elements are out of order %d < %d", after[i], after[i-1])
+		}
+	}
+	for _, rest := range after[10:] {
+		if rest < after[9] {
+			t.Errorf("%d should have been among the first elements", rest)
+		}
+	}
+}
+
+func TestPartialSortKeepsRestOrder(t *testing.T) {
+	q := New[int]()
+
+	for _, i := range []int{5, 9, 1, 8, 2, 7, 3} {
+		q.Append(i)
+	}
+	q.PartialSort(2, func(a int, b int) int { return a - b })
+
+	expected := []int{1, 2, 5, 9, 8, 7, 3}
+	for i, s := range q.ToSlice() {
+		if s != expected[i] {
+			t.Errorf("PartialSort should give %v, it gave %v", expected, q.ToSlice())
+			break
+		}
+	}
+
+	q.PartialSort(100, func(a int, b int) int { return a - b })
+	if p, _ := q.PeekAt(6); p != 9 {
+		t.Errorf("a k beyond the length should sort everything, there is %d at the back", p)
+	}
+}
+
+func TestQuickSortAfterPops(t *testing.T) {
+	q := New[int]()
+
+	for i := 0; i < 100; i++ {
+		q.Append(rand.Intn(1000))
+	}
+	for i := 0; i < 40; i++ {
+		q.Pop()
+	}
+	q.Remove(q.Front())
+	q.QuickSort(func(a int, b int) int { return a - b })
+
+	if q.Length() != 59 {
+		t.Errorf("Queue length should be 59, it is %d", q.Length())
+	}
+	prev := q.Pop()
+	for q.Length() > 0 {
+		cur := q.Pop()
+		if cur < prev {
+			t.Errorf("sort is wrong %d !< %d", prev, cur)
+		}
+		prev = cur
+	}
+}
+
+func TestIntroSortAdversarial(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	inputs := map[string][]int{
+		"sorted":   make([]int, 100000),
+		"reversed": make([]int, 100000),
+		"equal":    make([]int, 100000),
+		"organ":    make([]int, 100000),
+		"random":   make([]int, 100000),
+	}
+	for i := 0; i < 100000; i++ {
+		inputs["sorted"][i] = i
+		inputs["reversed"][i] = 100000 - i
+		inputs["equal"][i] = 7
+		if i < 50000 {
+			inputs["organ"][i] = i
+		} else {
+			inputs["organ"][i] = 100000 - i
+		}
+		inputs["random"][i] = rand.Int()
+	}
+
+	for name, data := range inputs {
+		comparisons := 0
+		introSort(data, func(a, b int) bool {
+			comparisons++
+			return less(a, b)
+		})
+		for i := 1; i < len(data); i++ {
+			if data[i] < data[i-1] {
+				t.Errorf("%s: sort is wrong at %d", name, i)
+				break
+			}
+		}
+		// n log n is about 1.7 million comparisons
+		if comparisons > 10000000 {
+			t.Errorf("%s: %d comparisons is not O(n log n)", name, comparisons)
+		}
+	}
+}
+
+func TestIntroSortSmall(t *testing.T) {
+	for n := 0; n < 50; n++ {
+		data := make([]int, n)
+		for i := range data {
+			data[i] = rand.Intn(10)
+		}
+		heaped := append([]int(nil), data...)
+		introSort(data, func(a, b int) bool { return a < b })
+		heapSort(heaped, func(a, b int) bool { return a < b })
+		for i := 1; i < n; i++ {
+			if data[i] < data[i-1] {
+				t.Errorf("sort of %d elements is wrong at %d", n, i)
+			}
+			if heaped[i] < heaped[i-1] {
+				t.Errorf("heapsort of %d elements is wrong at %d", n, i)
+			}
+		}
+	}
+}
+
+func TestStableSort(t *testing.T) {
+	type job struct {
+		priority, seq int
+	}
+	q := New[job]()
+
+	for i := 0; i < 1000; i++ {
+		q.Append(job{rand.Intn(10), i})
+	}
+	q.Pop()
+	q.StableSort(func(a job, b job) int { return a.priority - b.priority })
+
+	if q.Length() != 999 {
+		t.Errorf("Queue length should be 999, it is %d", q.Length())
+	}
+	prev := q.Pop()
+	for q.Length() > 0 {
+		cur := q.Pop()
+		if cur.priority < prev.priority {
+			t.Errorf("sort is wrong %d !< %d", prev.priority, cur.priority)
+		}
+		if cur.priority == prev.priority && cur.seq < prev.seq {
+			t.Errorf("equal elements should keep their order, %d came after %d", cur.seq, prev.seq)
+		}
+		prev = cur
+	}
+}
+
+func TestMergeSortLengths(t *testing.T) {
+	for _, n := range []int{0, 1, 11, 12, 13, 24, 25, 100, 1000, 4099} {
+		data := make([]int, n)
+		for i := range data {
+			data[i] = rand.Intn(50)
+		}
+		mergeSort(data, func(a, b int) bool { return a < b })
+		for i := 1; i < n; i++ {
+			if data[i] < data[i-1] {
+				t.Errorf("merge sort of %d elements is wrong at %d", n, i)
+				break
+			}
+		}
+	}
+}
+
+func TestSortOrdered(t *testing.T) {
+	q := New[string]()
+
+	for _, s := range []string{"pear", "apple", "fig", "banana"} {
+		q.Append(s)
+	}
+	SortOrdered(q)
+
+	for _, expected := range []string{"apple", "banana", "fig", "pear"} {
+		if p := q.Pop(); p != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	type job struct {
+		name     string
+		priority uint8
+	}
+	q := New[job]()
+
+	q.Append(job{"c", 2})
+	q.Append(job{"a", 1})
+	q.Append(job{"d", 2})
+	q.Append(job{"b", 1})
+	SortBy(q, func(j job) uint8 { return j.priority })
+
+	for _, expected := range []string{"a", "b", "c", "d"} {
+		if p := q.Pop(); p.name != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p.name)
+		}
+	}
+}
+
+func TestSortKeepsBufferSize(t *testing.T) {
+	q := New[int](WithInitialCapacity[int](1024))
+	for i := 3; i > 0; i-- {
+		q.Append(i)
+	}
+	q.QuickSort(func(a, b int) int { return a - b })
+	if len(q.buf) != 1024 {
+		t.Errorf("Sorting should keep the initial capacity of 1024, the buffer has %d slots", len(q.buf))
+	}
+
+	kept := New[int](WithoutShrinking[int]())
+	for i := 0; i < 1000; i++ {
+		kept.Append(i)
+	}
+	for i := 0; i < 990; i++ {
+		kept.Pop()
+	}
+	size := len(kept.buf)
+	kept.StableSort(func(a, b int) int { return b - a })
+	if len(kept.buf) != size {
+		t.Errorf("Sorting should keep the buffer with WithoutShrinking, it went from %d to %d slots", size, len(kept.buf))
+	}
+	if item := kept.Pop(); item != 999 {
+		t.Errorf("There should be 999 in front after sorting, there is %d", item)
+	}
+}
@@ -0,0 +1,31 @@
+package queue
+
+// NewSorted creates a queue that keeps its elements ordered by cmp, smallest first.
+// Append inserts an element behind the elements it is equal to and Prepend in
+// front of them, so Pop always returns the smallest element. Operations that
+// place elements at an explicit position, such as InsertAt, Set, Swap, Rotate,
+// MoveToFront and MoveToBack, are not re-sorted.
+// cmp returns a negative number when elem1 is smaller than elem2, zero when
+// they are equal and a positive number otherwise
+func NewSorted[T comparable](cmp func(elem1 T, elem2 T) int, opts ...Option[T]) *Queue[T] {
+	q := New[T](opts...)
+	q.cmp = cmp
+	return q
+}
+
+// search returns the position at which elem keeps the queue sorted, using binary search.
+// If before is true the position is in front of equal elements, otherwise behind them
+func (q *Queue[T]) search(elem T, before bool) int {
+	lo, hi := 0, q.size
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		pos, _ := q.locate(mid)
+		c := q.cmp(q.buf[pos].elem, elem)
+		if c < 0 || (c == 0 && !before) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
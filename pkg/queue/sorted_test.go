@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	q := NewSorted(func(a int, b int) int { return a - b })
+
+	for i := 0; i < 1000; i++ {
+		if i%3 == 0 {
+			q.Prepend(rand.Intn(500))
+		} else {
+			q.Append(rand.Intn(500))
+		}
+	}
+	q.Remove(q.Front())
+
+	prev := q.Pop()
+	for q.Length() > 0 {
+		cur := q.Pop()
+		if cur < prev {
+			t.Errorf("sorted queue is out of order %d < %d", cur, prev)
+		}
+		prev = cur
+	}
+}
+
+func TestSortedEqualElements(t *testing.T) {
+	type job struct {
+		priority int
+		name     string
+	}
+	q := NewSorted(func(a job, b job) int { return a.priority - b.priority })
+
+	q.Append(job{2, "b1"})
+	q.Append(job{1, "a1"})
+	q.Append(job{2, "b2"})
+	q.Prepend(job{2, "b0"})
+	q.Append(job{3, "c1"})
+
+	for _, expected := range []string{"a1", "b0", "b1", "b2", "c1"} {
+		if p := q.Pop(); p.name != expected {
+			t.Errorf("There should be %s on pop, there is %s", expected, p.name)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrSpillLimit is returned by NewSpill when the memory limit is not positive
+var ErrSpillLimit = errors.New("queue: spill limit must be positive")
+
+// SpillQueue is an unbounded FIFO queue that keeps at most a fixed number of
+// elements in memory. Once that many are queued, further elements are written to
+// a temporary file and read back in order as the front of the queue is popped
+type SpillQueue[T any] struct {
+	q     *Queue[T]
+	limit int
+	codec Codec[T]
+	file  *os.File
+	// elements in the file and where the next one is read and written
+	spilled    int
+	rOff, wOff int64
+}
+
+// NewSpill creates a queue that keeps up to limit elements in memory and spills
+// the rest to a temporary file in dir, the default directory for temporary files
+// if dir is empty. Elements are encoded with the codec set by WithCodec
+func NewSpill[T any](dir string, limit int, opts ...Option[T]) (*SpillQueue[T], error) {
+	if limit < 1 {
+		return nil, ErrSpillLimit
+	}
+	file, err := os.CreateTemp(dir, "queue-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	return &SpillQueue[T]{
+		q:     NewAny[T](),
+		limit: limit,
+		codec: newSettings(opts).codec,
+		file:  file,
+	}, nil
+}
+
+// Returns the number of elements in queue, in memory and on disk
+func (s *SpillQueue[T]) Length() int {
+	s.q.mutex.Lock()
+	defer s.q.mutex.Unlock()
+
+	return s.q.size + s.spilled
+}
+
+// Spilled returns the number of elements that are on disk
+func (s *SpillQueue[T]) Spilled() int {
+	s.q.mutex.Lock()
+	defer s.q.mutex.Unlock()
+
+	return s.spilled
+}
+
+// Append adds elem at the back of the queue, on disk if the memory limit is reached.
+// Appending to a closed queue returns ErrClosed
+func (s *SpillQueue[T]) Append(elem T) error {
+	s.q.mutex.Lock()
+	defer s.q.unlock()
+
+	if s.q.closed {
+		return ErrClosed
+	}
+	// once anything is on disk, newer elements have to queue up behind it
+	if s.spilled == 0 && s.q.size < s.limit {
+		s.q.append(elem)
+		return nil
+	}
+
+	data, err := s.codec.Encode(elem)
+	if err != nil {
+		return err
+	}
+	record := make([]byte, binary.MaxVarintLen64+len(data))
+	n := binary.PutUvarint(record, uint64(len(data)))
+	record = append(record[:n], data...)
+	if _, err := s.file.WriteAt(record, s.wOff); err != nil {
+		return err
+	}
+	s.wOff += int64(len(record))
+	s.spilled++
+	// a consumer waiting on the empty memory queue loads this element itself
+	s.q.notEmpty.Broadcast()
+	return nil
+}
+
+// AppendContext works like Append, it fails with ctx.Err() if ctx is already done
+func (s *SpillQueue[T]) AppendContext(ctx context.Context, elem T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Append(elem)
+}
+
+// Take removes and returns the element at the front, blocking while the queue is empty.
+// Once the queue is closed and empty it returns ErrClosed
+func (s *SpillQueue[T]) Take() (T, error) {
+	return s.PopContext(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (s *SpillQueue[T]) PopContext(ctx context.Context) (T, error) {
+	s.q.mutex.Lock()
+	defer s.q.unlock()
+
+	// elements can be spilled while this call waits, so load before every wait
+	for {
+		if err := s.load(); err != nil {
+			var zero T
+			return zero, err
+		}
+		if s.q.count > 0 || s.q.closed {
+			break
+		}
+		if err := waitContext(ctx, s.q.notEmpty); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	e, err := s.q.takeEntry(ctx, s.q.popFront)
+	return e.elem, err
+}
+
+// load reads spilled elements back until the memory limit is reached. An element
+// that cannot be decoded is skipped and its error returned, so it does not block
+// the elements behind it
+func (s *SpillQueue[T]) load() error {
+	for s.spilled > 0 && s.q.size < s.limit {
+		var header [binary.MaxVarintLen64]byte
+		n, err := s.file.ReadAt(header[:], s.rOff)
+		if n == 0 {
+			return unexpectedEOF(err)
+		}
+		size, m := binary.Uvarint(header[:n])
+		if m <= 0 {
+			return ErrCorrupt
+		}
+		data := make([]byte, size)
+		if _, err := s.file.ReadAt(data, s.rOff+int64(m)); err != nil {
+			return unexpectedEOF(err)
+		}
+		s.rOff += int64(m) + int64(size)
+		s.spilled--
+		elem, err := s.codec.Decode(data)
+		if err != nil {
+			return fmt.Errorf("queue: spilled element cannot be decoded and was dropped: %w", err)
+		}
+		s.q.append(elem)
+	}
+	if s.spilled == 0 && s.wOff > 0 {
+		// start the file over once it has been read completely
+		s.rOff, s.wOff = 0, 0
+		return s.file.Truncate(0)
+	}
+	return nil
+}
+
+// Close wakes up blocked calls, discards the elements that were not popped, in
+// memory and on disk alike, and removes the temporary file. Closing it again does nothing
+func (s *SpillQueue[T]) Close() error {
+	s.q.Close()
+
+	s.q.mutex.Lock()
+	defer s.q.unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	s.q.reset()
+	s.spilled = 0
+	s.rOff, s.wOff = 0, 0
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file = nil
+	return err
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSpillQueue(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpill[int](dir, 3)
+	if err != nil {
+		t.Fatalf("NewSpill should succeed, got %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		s.Append(i)
+	}
+	if s.Length() != 10 || s.Spilled() != 7 {
+		t.Errorf("7 of 10 elements should be on disk, %d of %d are", s.Spilled(), s.Length())
+	}
+	for i := 0; i < 5; i++ {
+		if item, err := s.Take(); err != nil || item != i {
+			t.Errorf("There should be %d on take, there is %v (%v)", i, item, err)
+		}
+	}
+	s.Append(10)
+	for i := 5; i <= 10; i++ {
+		if item, err := s.Take(); err != nil || item != i {
+			t.Errorf("There should be %d on take, there is %v (%v)", i, item, err)
+		}
+	}
+	if s.Length() != 0 || s.Spilled() != 0 {
+		t.Errorf("Queue should be empty, length is %d", s.Length())
+	}
+
+	s.Close()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Close should remove the spill file, %d files are left", len(entries))
+	}
+}
+
+func TestSpillLimit(t *testing.T) {
+	if _, err := NewSpill[int](t.TempDir(), 0); err != ErrSpillLimit {
+		t.Errorf("NewSpill should reject a limit of 0, got %v", err)
+	}
+}
+
+func TestSpillConcurrentTake(t *testing.T) {
+	s, err := NewSpill[int](t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("NewSpill should succeed, got %v", err)
+	}
+	defer s.Close()
+
+	const n = 200
+	go func() {
+		for i := 0; i < n; i++ {
+			s.Append(i)
+		}
+	}()
+	for i := 0; i < n; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		item, err := s.PopContext(ctx)
+		cancel()
+		if err != nil || item != i {
+			t.Fatalf("There should be %d on take, there is %v (%v)", i, item, err)
+		}
+	}
+}
+
+// oddCodec cannot decode odd numbers
+type oddCodec struct {
+	JSONCodec[int]
+}
+
+func (c oddCodec) Decode(data []byte) (int, error) {
+	elem, err := c.JSONCodec.Decode(data)
+	if err == nil && elem%2 == 1 {
+		return 0, errors.New("odd")
+	}
+	return elem, err
+}
+
+func TestSpillCorruptElement(t *testing.T) {
+	s, err := NewSpill[int](t.TempDir(), 1, WithCodec[int](oddCodec{}))
+	if err != nil {
+		t.Fatalf("NewSpill should succeed, got %v", err)
+	}
+	for _, elem := range []int{0, 1, 2} {
+		s.Append(elem)
+	}
+
+	if item, err := s.Take(); err != nil || item != 0 {
+		t.Errorf("There should be 0 on take, there is %v (%v)", item, err)
+	}
+	if _, err := s.Take(); err == nil {
+		t.Error("Take should return the error of the element that cannot be decoded")
+	}
+	if item, err := s.Take(); err != nil || item != 2 {
+		t.Errorf("There should be 2 behind the dropped element, there is %v (%v)", item, err)
+	}
+
+	s.Append(4)
+	s.Append(6)
+	if err := s.Close(); err != nil {
+		t.Errorf("Close should succeed, got %v", err)
+	}
+	if s.Length() != 0 {
+		t.Errorf("Close should discard the elements in memory and on disk, %d are left", s.Length())
+	}
+	if _, err := s.Take(); err != ErrClosed {
+		t.Errorf("Take after Close should return ErrClosed, got %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("A second Close should do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package queue
+
+import "sync/atomic"
+
+// SPSCQueue is a bounded FIFO queue for exactly one producer goroutine and one
+// consumer goroutine. It uses no locks: the producer only writes the tail and the
+// consumer only writes the head, both atomically, which makes it much cheaper
+// than Queue for passing elements between two pipeline stages.
+// Calling TryAppend from more than one goroutine, or TryPop from more than one,
+// corrupts the queue
+type SPSCQueue[T any] struct {
+	// head and tail count the elements popped and appended so far, they are
+	// kept on separate cache lines so the two goroutines do not slow each other down.
+	// They come first to be 64-bit aligned on 32-bit platforms
+	head uint64
+	_    [56]byte
+	tail uint64
+	_    [56]byte
+	buf  []T
+	mask uint64
+}
+
+// NewSPSC creates a queue that holds at least capacity elements, capacity is
+// rounded up to a power of two. Panics if capacity is not positive
+func NewSPSC[T any](capacity int) *SPSCQueue[T] {
+	if capacity <= 0 {
+		panic("queue: capacity must be positive")
+	}
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	return &SPSCQueue[T]{buf: make([]T, size), mask: uint64(size - 1)}
+}
+
+// Capacity returns the maximum number of elements
+func (s *SPSCQueue[T]) Capacity() int {
+	return len(s.buf)
+}
+
+// Length returns the number of queued elements, it may be outdated by the
+// time it is returned
+func (s *SPSCQueue[T]) Length() int {
+	head := atomic.LoadUint64(&s.head)
+	return int(atomic.LoadUint64(&s.tail) - head)
+}
+
+// TryAppend adds elem at the back of the queue, it returns false if the queue is full.
+// Only the producer goroutine may call it
+func (s *SPSCQueue[T]) TryAppend(elem T) bool {
+	tail := atomic.LoadUint64(&s.tail)
+	if tail-atomic.LoadUint64(&s.head) == uint64(len(s.buf)) {
+		return false
+	}
+	s.buf[tail&s.mask] = elem
+	atomic.StoreUint64(&s.tail, tail+1)
+	return true
+}
+
+// TryPop removes and returns the element at the front of the queue, it returns
+// false if the queue is empty. Only the consumer goroutine may call it
+func (s *SPSCQueue[T]) TryPop() (T, bool) {
+	var zero T
+	head := atomic.LoadUint64(&s.head)
+	if head == atomic.LoadUint64(&s.tail) {
+		return zero, false
+	}
+	elem := s.buf[head&s.mask]
+	// do not keep the element alive
+	s.buf[head&s.mask] = zero
+	atomic.StoreUint64(&s.head, head+1)
+	return elem, true
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestSPSCQueue(t *testing.T) {
+	q := NewSPSC[int](5)
+	if q.Capacity() != 8 {
+		t.Errorf("capacity should be rounded up to 8, got %d", q.Capacity())
+	}
+	for i := 0; i < 8; i++ {
+		if !q.TryAppend(i) {
+			t.Fatalf("append %d should fit", i)
+		}
+	}
+	if q.TryAppend(8) {
+		t.Error("TryAppend should fail on a full queue")
+	}
+	for i := 0; i < 8; i++ {
+		if elem, ok := q.TryPop(); !ok || elem != i {
+			t.Fatalf("expected %d, got %d, %v", i, elem, ok)
+		}
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop should fail on an empty queue")
+	}
+}
+
+func TestSPSCQueueConcurrent(t *testing.T) {
+	const n = 100000
+	q := NewSPSC[int](64)
+	go func() {
+		for i := 0; i < n; i++ {
+			for !q.TryAppend(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	for i := 0; i < n; {
+		elem, ok := q.TryPop()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+		if elem != i {
+			t.Fatalf("expected %d, got %d", i, elem)
+		}
+		i++
+	}
+	if q.Length() != 0 {
+		t.Errorf("expected an empty queue, length is %d", q.Length())
+	}
+}
+
+func BenchmarkSPSCTickTock(b *testing.B) {
+	q := NewSPSC[int](minQueueLen)
+	for i := 0; i < b.N; i++ {
+		q.TryAppend(i)
+		q.TryPop()
+	}
+}
@@ -0,0 +1,200 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SQLQueue is a FIFO queue stored in a SQLite table, one row per element ordered
+// by a sequence column, so pending elements can be inspected and repaired with
+// plain SQL. It works with any database/sql SQLite driver the caller opened db with.
+// Blocked calls only wake up for elements appended through the same SQLQueue.
+// A row that cannot be decoded is moved to the table named after the queue's with
+// a _corrupt suffix, so it does not block the rows behind it
+type SQLQueue[T any] struct {
+	db       *sql.DB
+	codec    Codec[T]
+	mutex    *sync.Mutex
+	notEmpty *sync.Cond
+	closed   bool
+
+	insert, first, remove, count, bury string
+}
+
+// NewSQL creates table and its _corrupt table if they do not exist yet and returns
+// a queue stored in them. Elements are encoded with the codec set by WithCodec,
+// the other options do not apply
+func NewSQL[T any](db *sql.DB, table string, opts ...Option[T]) (*SQLQueue[T], error) {
+	name := quoteSQL(table)
+	corrupt := quoteSQL(table + "_corrupt")
+	_, err := db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (seq INTEGER PRIMARY KEY AUTOINCREMENT, data BLOB NOT NULL)", name))
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (seq INTEGER PRIMARY KEY, data BLOB NOT NULL)", corrupt))
+	if err != nil {
+		return nil, err
+	}
+
+	q := &SQLQueue[T]{
+		db:     db,
+		codec:  newSettings(opts).codec,
+		mutex:  &sync.Mutex{},
+		insert: fmt.Sprintf("INSERT INTO %s (data) VALUES (?)", name),
+		first:  fmt.Sprintf("SELECT seq, data FROM %s ORDER BY seq LIMIT 1", name),
+		remove: fmt.Sprintf("DELETE FROM %s WHERE seq = ?", name),
+		count:  fmt.Sprintf("SELECT COUNT(*) FROM %s", name),
+		bury:   fmt.Sprintf("INSERT INTO %s (seq, data) VALUES (?, ?)", corrupt),
+	}
+	q.notEmpty = sync.NewCond(q.mutex)
+	return q, nil
+}
+
+// quoteSQL quotes name as an SQL identifier
+func quoteSQL(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Returns the number of rows in the table, or 0 if it cannot be read
+func (q *SQLQueue[T]) Length() int {
+	n, _ := q.Count()
+	return n
+}
+
+// Count returns the number of rows in the table
+func (q *SQLQueue[T]) Count() (int, error) {
+	var n int
+	err := q.db.QueryRow(q.count).Scan(&n)
+	return n, err
+}
+
+// Append adds elem as the last row. Appending to a closed queue returns ErrClosed
+func (q *SQLQueue[T]) Append(elem T) error {
+	return q.AppendContext(context.Background(), elem)
+}
+
+// AppendContext works like Append, ctx is passed on to the database
+func (q *SQLQueue[T]) AppendContext(ctx context.Context, elem T) error {
+	data, err := q.codec.Encode(elem)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	if _, err := q.db.ExecContext(ctx, q.insert, data); err != nil {
+		return err
+	}
+	q.notEmpty.Broadcast()
+	return nil
+}
+
+// Front returns the first element without removing it, ok is false if the table is empty
+func (q *SQLQueue[T]) Front() (elem T, ok bool, err error) {
+	var seq int64
+	var data []byte
+	err = q.db.QueryRow(q.first).Scan(&seq, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return elem, false, nil
+	}
+	if err != nil {
+		return elem, false, err
+	}
+	elem, err = q.codec.Decode(data)
+	return elem, err == nil, err
+}
+
+// TryPop removes and returns the first element without blocking,
+// ok is false if the table is empty
+func (q *SQLQueue[T]) TryPop() (elem T, ok bool, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.pop(context.Background())
+}
+
+// Take removes and returns the first element, blocking while the table is empty.
+// Once the queue is closed and empty it returns ErrClosed
+func (q *SQLQueue[T]) Take() (T, error) {
+	return q.PopContext(context.Background())
+}
+
+// PopContext works like Take, but gives up with ctx.Err() once ctx is done
+func (q *SQLQueue[T]) PopContext(ctx context.Context) (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for {
+		elem, ok, err := q.pop(ctx)
+		if ok || err != nil {
+			return elem, err
+		}
+		if q.closed {
+			return elem, ErrClosed
+		}
+		if err := waitContext(ctx, q.notEmpty); err != nil {
+			return elem, err
+		}
+	}
+}
+
+// pop deletes the first row in a transaction, the mutex must be held.
+// A row that cannot be decoded is moved to the _corrupt table and the decoding
+// error is returned, the next call goes on with the row after it
+func (q *SQLQueue[T]) pop(ctx context.Context) (elem T, ok bool, err error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return elem, false, err
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	var data []byte
+	err = tx.QueryRowContext(ctx, q.first).Scan(&seq, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return elem, false, nil
+	}
+	if err != nil {
+		return elem, false, err
+	}
+	if elem, err = q.codec.Decode(data); err != nil {
+		if _, err := tx.ExecContext(ctx, q.bury, seq, data); err != nil {
+			return elem, false, err
+		}
+		if _, err := tx.ExecContext(ctx, q.remove, seq); err != nil {
+			return elem, false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return elem, false, err
+		}
+		return elem, false, fmt.Errorf("queue: row %d cannot be decoded and was moved to the _corrupt table: %w", seq, err)
+	}
+	if _, err = tx.ExecContext(ctx, q.remove, seq); err != nil {
+		return elem, false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return elem, false, err
+	}
+	return elem, true, nil
+}
+
+// Close stops the queue from accepting new elements and wakes up blocked calls.
+// The rows stay in the table, db is not closed. It always returns nil
+func (q *SQLQueue[T]) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.notEmpty.Broadcast()
+	return nil
+}
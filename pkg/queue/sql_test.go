@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSQL is a database/sql driver that understands just the statements
+// SQLQueue sends, it keeps the tables of every data source name in memory
+type fakeSQL struct {
+	mutex  sync.Mutex
+	tables map[string]*fakeTable
+}
+
+type fakeTable struct {
+	seq  int64
+	rows [][2]driver.Value
+}
+
+var fakeDriver = &fakeSQL{tables: map[string]*fakeTable{}}
+
+func init() {
+	sql.Register("queuetest", fakeDriver)
+}
+
+func (d *fakeSQL) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d, name}, nil
+}
+
+// table returns the table query refers to by its quoted name, the mutex must be held
+func (d *fakeSQL) table(dsn, query string) *fakeTable {
+	name := dsn + "/" + strings.Split(query, `"`)[1]
+	if d.tables[name] == nil {
+		d.tables[name] = &fakeTable{}
+	}
+	return d.tables[name]
+}
+
+type fakeConn struct {
+	d   *fakeSQL
+	dsn string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *fakeConn) Commit() error                             { return nil }
+func (c *fakeConn) Rollback() error                           { return nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mutex.Lock()
+	defer s.c.d.mutex.Unlock()
+	t := s.c.d.table(s.c.dsn, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "INSERT") && len(args) == 2:
+		t.rows = append(t.rows, [2]driver.Value{args[0], args[1]})
+	case strings.HasPrefix(s.query, "INSERT"):
+		t.seq++
+		t.rows = append(t.rows, [2]driver.Value{t.seq, args[0]})
+	case strings.HasPrefix(s.query, "DELETE"):
+		for i, row := range t.rows {
+			if row[0] == args[0] {
+				t.rows = append(t.rows[:i], t.rows[i+1:]...)
+				break
+			}
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mutex.Lock()
+	defer s.c.d.mutex.Unlock()
+	t := s.c.d.table(s.c.dsn, s.query)
+	if strings.Contains(s.query, "COUNT") {
+		return &fakeRows{cols: []string{"count"}, rows: [][]driver.Value{{int64(len(t.rows))}}}, nil
+	}
+	rows := &fakeRows{cols: []string{"seq", "data"}}
+	if len(t.rows) > 0 {
+		rows.rows = [][]driver.Value{{t.rows[0][0], t.rows[0][1]}}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLQueue(t *testing.T) {
+	db, err := sql.Open("queuetest", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	q, err := NewSQL[string](db, "jobs")
+	if err != nil {
+		t.Fatalf("NewSQL should succeed, got %v", err)
+	}
+	if _, ok, err := q.TryPop(); ok || err != nil {
+		t.Errorf("TryPop on an empty table should return nothing, got %v", err)
+	}
+	q.Append("first")
+	q.Append("second")
+
+	if n, err := q.Count(); n != 2 || err != nil || q.Length() != 2 {
+		t.Errorf("Queue length should be 2, it is %d (%v)", n, err)
+	}
+	if item, ok, err := q.Front(); !ok || item != "first" {
+		t.Errorf("There should be first in front, there is %v (%v)", item, err)
+	}
+	for _, expected := range []string{"first", "second"} {
+		if item, err := q.Take(); err != nil || item != expected {
+			t.Errorf("There should be %s on take, there is %v (%v)", expected, item, err)
+		}
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Append("late")
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if item, err := q.PopContext(ctx); err != nil || item != "late" {
+		t.Errorf("There should be late on pop, there is %v (%v)", item, err)
+	}
+
+	q.Close()
+	if _, err := q.Take(); err != ErrClosed {
+		t.Errorf("Take should return ErrClosed, got %v", err)
+	}
+	if err := q.Append("closed"); err != ErrClosed {
+		t.Errorf("Append should return ErrClosed, got %v", err)
+	}
+}
+
+func TestSQLQueueCorruptRow(t *testing.T) {
+	db, err := sql.Open("queuetest", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	q, err := NewSQL[int](db, "jobs")
+	if err != nil {
+		t.Fatalf("NewSQL should succeed, got %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO "jobs" (data) VALUES (?)`, []byte("not a number")); err != nil {
+		t.Fatal(err)
+	}
+	q.Append(1)
+
+	if _, ok, err := q.TryPop(); ok || err == nil {
+		t.Error("TryPop should return the decoding error of the corrupt row")
+	}
+	if item, ok, err := q.TryPop(); !ok || err != nil || item != 1 {
+		t.Errorf("There should be 1 behind the corrupt row, there is %v (%v)", item, err)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM "jobs_corrupt"`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("The corrupt row should be moved to jobs_corrupt, it holds %d rows (%v)", n, err)
+	}
+}
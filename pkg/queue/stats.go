@@ -0,0 +1,38 @@
+package queue
+
+// Stats holds the counters of a queue at one point in time, see Queue.Stats
+type Stats struct {
+	// Appends counts the elements added since the queue was created
+	Appends uint64
+	// Pops counts the elements taken by Pop, Take, Reserve, Drain and the like
+	Pops uint64
+	// Removes counts the elements removed by Remove, RemoveFunc and Clean, moved
+	// out by MergeFrom, SplitAt, Partition and GroupBy or evicted by a full bounded queue
+	Removes uint64
+	// Resizes counts how often the ring buffer was reallocated
+	Resizes uint64
+	// Length is the number of queued elements and Peak the highest it has been
+	Length, Peak int
+	// BufferCapacity is the number of slots in the ring buffer
+	BufferCapacity int
+	// Latency holds how long the popped elements were queued,
+	// it is empty unless the queue was created with WithLatencyHistogram
+	Latency Histogram
+}
+
+// Stats returns the counters of the queue
+func (q *Queue[T]) Stats() Stats {
+	q.rmutex.Lock()
+	defer q.rmutex.Unlock()
+
+	return Stats{
+		Appends:        q.appends,
+		Pops:           q.pops,
+		Removes:        q.removes,
+		Resizes:        q.resizes,
+		Length:         q.size,
+		Peak:           q.peak,
+		BufferCapacity: len(q.buf),
+		Latency:        q.latencies,
+	}
+}
@@ -0,0 +1,22 @@
+package queue
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	q := NewBoundedWithPolicy[int](40, OverflowDropOldest)
+	for i := 0; i < 41; i++ {
+		q.Append(i)
+	}
+	q.Pop()
+	q.Remove(10)
+	q.RemoveFunc(func(elem int) bool { return elem > 35 })
+
+	s := q.Stats()
+	want := Stats{Appends: 41, Pops: 1, Removes: 7, Resizes: 1, Length: 33, Peak: 40, BufferCapacity: len(q.buf)}
+	if s != want {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+	if s.BufferCapacity <= minQueueLen {
+		t.Errorf("buffer should have grown past %d, capacity is %d", minQueueLen, s.BufferCapacity)
+	}
+}
@@ -0,0 +1,40 @@
+package queue
+
+// Subscribe returns a channel of its own for one consumer, which receives a
+// signal whenever the queue is not empty. Unlike NotEmpty, which all consumers
+// share, a signal on it is not taken away by another consumer. The channel is
+// closed by Close, call Unsubscribe once it is not needed anymore
+func (q *Queue[T]) Subscribe() <-chan struct{} {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	c := make(chan struct{}, 1)
+	if q.closed {
+		close(c)
+		return c
+	}
+	if q.subscribers == nil {
+		q.subscribers = make(map[<-chan struct{}]chan struct{})
+	}
+	q.subscribers[c] = c
+	if q.size > 0 {
+		signal(c)
+	}
+	return c
+}
+
+// Unsubscribe stops signalling a channel returned by Subscribe
+func (q *Queue[T]) Unsubscribe(c <-chan struct{}) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.subscribers, c)
+}
+
+// signal sends on c unless a signal is already pending
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
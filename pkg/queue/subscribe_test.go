@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	q := New[int]()
+	a := q.Subscribe()
+	b := q.Subscribe()
+
+	q.Append(1)
+	for name, c := range map[string]<-chan struct{}{"a": a, "b": b} {
+		select {
+		case <-c:
+		default:
+			t.Errorf("Subscriber %s should be signalled", name)
+		}
+	}
+
+	q.Unsubscribe(b)
+	q.Append(2)
+	select {
+	case <-b:
+		t.Error("An unsubscribed channel should not be signalled")
+	default:
+	}
+	if _, ok := <-a; !ok {
+		t.Error("Subscriber a should be signalled again")
+	}
+
+	if late := q.Subscribe(); len(late) != 1 {
+		t.Error("Subscribing to a queue that is not empty should signal right away")
+	}
+
+	q.Close()
+	if _, ok := <-a; ok {
+		t.Error("Close should close subscribed channels")
+	}
+	if _, ok := <-q.Subscribe(); ok {
+		t.Error("Subscribing to a closed queue should return a closed channel")
+	}
+}
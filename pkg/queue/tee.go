@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// Tee delivers every element appended to it to each of its attached queues, so
+// that several groups of consumers can process the same stream at their own pace.
+// An attached queue that is bounded holds up Append while it is full, unless its
+// OverflowPolicy drops elements, so give slow groups a policy that suits them
+type Tee[T any] struct {
+	mutex  sync.RWMutex
+	queues []*Queue[T]
+	closed bool
+}
+
+// NewTee creates a Tee without attached queues
+func NewTee[T any]() *Tee[T] {
+	return &Tee[T]{}
+}
+
+// Attach creates a queue with opts that receives every element appended from now on.
+// It is created like NewAny, so it cannot look elements up by value
+func (t *Tee[T]) Attach(opts ...Option[T]) *Queue[T] {
+	q := NewAny[T](opts...)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		q.Close()
+		return q
+	}
+	t.queues = append(t.queues, q)
+	return q
+}
+
+// Detach stops delivering elements to q and closes it, so its consumers can
+// finish the elements it still holds. It returns false if q is not attached
+func (t *Tee[T]) Detach(q *Queue[T]) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for i, attached := range t.queues {
+		if attached == q {
+			t.queues = append(t.queues[:i], t.queues[i+1:]...)
+			q.Close()
+			return true
+		}
+	}
+	return false
+}
+
+// Append delivers elem to every attached queue. It returns false if the Tee is closed
+func (t *Tee[T]) Append(elem T) bool {
+	return t.AppendContext(context.Background(), elem) == nil
+}
+
+// AppendContext delivers elem to every attached queue like Queue.AppendContext.
+// It returns ErrClosed if the Tee is closed and ctx.Err() if ctx is done while a
+// full queue holds it up, elem then only reached the queues before that one
+func (t *Tee[T]) AppendContext(ctx context.Context, elem T) error {
+	t.mutex.RLock()
+	if t.closed {
+		t.mutex.RUnlock()
+		return ErrClosed
+	}
+	// a full queue may block the append, so the lock must not be held for it,
+	// otherwise Close and Detach could never unblock it
+	queues := append([]*Queue[T](nil), t.queues...)
+	t.mutex.RUnlock()
+
+	for _, q := range queues {
+		if err := q.AppendContext(ctx, elem); err != nil && err != ErrClosed {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the Tee and every attached queue, their consumers can still pop
+// the elements that were delivered
+func (t *Tee[T]) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	for _, q := range t.queues {
+		q.Close()
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTee(t *testing.T) {
+	tee := NewTee[int]()
+	a := tee.Attach()
+	b := tee.Attach(WithBound[int](2), WithOverflowPolicy[int](OverflowDropOldest))
+
+	for i := 0; i < 3; i++ {
+		tee.Append(i)
+	}
+	if s := a.ToSlice(); len(s) != 3 || s[0] != 0 {
+		t.Errorf("a should hold 0 1 2, it holds %v", s)
+	}
+	if s := b.ToSlice(); len(s) != 2 || s[0] != 1 {
+		t.Errorf("b should hold 1 2, it holds %v", s)
+	}
+
+	if !tee.Detach(b) || tee.Detach(b) || !b.Closed() {
+		t.Error("b should be detached and closed once")
+	}
+	tee.Append(3)
+	if a.Length() != 4 || b.Length() != 2 {
+		t.Errorf("Only a should get 3, lengths are %d and %d", a.Length(), b.Length())
+	}
+
+	tee.Close()
+	if tee.Append(4) || !a.Closed() {
+		t.Error("A closed tee should close its queues and reject elements")
+	}
+	if c := tee.Attach(); !c.Closed() {
+		t.Error("A queue attached to a closed tee should be closed")
+	}
+}
+
+func TestTeeCloseUnblocksAppend(t *testing.T) {
+	tee := NewTee[int]()
+	tee.Attach(WithBound[int](1))
+	tee.Append(1)
+
+	done := make(chan bool)
+	go func() {
+		done <- tee.Append(2)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	tee.Close()
+	<-done
+}
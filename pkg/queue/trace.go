@@ -0,0 +1,85 @@
+package queue
+
+import "context"
+
+// Tracer is what TracedQueue needs from a tracing library. An adapter for
+// OpenTelemetry starts spans with a trace.Tracer and moves the span context in and
+// out of the headers with a propagation.TextMapPropagator, a dequeue span can
+// then either be a child of the span that enqueued the element or link to it
+type Tracer interface {
+	// Start starts a span called name as a child of the span in ctx. It returns
+	// a context holding the new span and a function that ends it with the
+	// outcome of the operation
+	Start(ctx context.Context, name string) (context.Context, func(err error))
+	// Inject writes the span context of ctx into headers
+	Inject(ctx context.Context, headers map[string]string)
+	// Extract returns ctx with the span context read from headers
+	Extract(ctx context.Context, headers map[string]string) context.Context
+}
+
+// Names of the spans started by TracedQueue
+const (
+	SpanEnqueue = "queue.enqueue"
+	SpanDequeue = "queue.dequeue"
+)
+
+// TracedQueue wraps a Queue so that a trace continues across it: the span
+// context of the producer travels with the element in its headers and the
+// consumer gets a context that continues from it
+type TracedQueue[T any] struct {
+	q      *Queue[T]
+	tracer Tracer
+}
+
+// NewTraced wraps q, elements appended to q directly are popped without a trace
+func NewTraced[T any](q *Queue[T], tracer Tracer) *TracedQueue[T] {
+	return &TracedQueue[T]{q: q, tracer: tracer}
+}
+
+// Queue returns the wrapped queue
+func (t *TracedQueue[T]) Queue() *Queue[T] {
+	return t.q
+}
+
+// Append adds elem at the back of the queue like AppendContext within a SpanEnqueue
+// span and stores the span context in the headers of the element
+func (t *TracedQueue[T]) Append(ctx context.Context, elem T) (Handle, error) {
+	ctx, end := t.tracer.Start(ctx, SpanEnqueue)
+	headers := make(map[string]string)
+	t.tracer.Inject(ctx, headers)
+
+	h, err := t.q.appendWithHeaders(ctx, elem, headers)
+	end(err)
+	return h, err
+}
+
+// Pop removes the element at the front of the queue like PopContext and records
+// a SpanDequeue span that continues the trace of the producer. The returned
+// context holds that span, use it to trace the processing of the element
+func (t *TracedQueue[T]) Pop(ctx context.Context) (context.Context, Envelope[T], error) {
+	return t.dequeue(ctx, func() (entry[T], error) {
+		e, err := t.q.takeEntry(ctx, t.q.popFront)
+		e.meta.deliveries++
+		return e, err
+	})
+}
+
+// Reserve works like Pop, but reserves the element like ReserveContext
+func (t *TracedQueue[T]) Reserve(ctx context.Context) (context.Context, Envelope[T], error) {
+	return t.dequeue(ctx, func() (entry[T], error) {
+		return t.q.reserve(ctx)
+	})
+}
+
+func (t *TracedQueue[T]) dequeue(ctx context.Context, take func() (entry[T], error)) (context.Context, Envelope[T], error) {
+	t.q.mutex.Lock()
+	e, err := take()
+	t.q.unlock()
+	if err != nil {
+		return ctx, Envelope[T]{}, err
+	}
+
+	ctx, end := t.tracer.Start(t.tracer.Extract(ctx, e.meta.headers), SpanDequeue)
+	end(nil)
+	return ctx, e.envelope(), nil
+}
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type spanKey struct{}
+
+// fakeTracer names spans by counting them and records each with its parent
+type fakeTracer struct {
+	spans []string
+}
+
+func (f *fakeTracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	span := name + "#" + string(rune('0'+len(f.spans)))
+	f.spans = append(f.spans, parent+">"+span)
+	return context.WithValue(ctx, spanKey{}, span), func(error) {}
+}
+
+func (f *fakeTracer) Inject(ctx context.Context, headers map[string]string) {
+	if span, ok := ctx.Value(spanKey{}).(string); ok {
+		headers["span"] = span
+	}
+}
+
+func (f *fakeTracer) Extract(ctx context.Context, headers map[string]string) context.Context {
+	if span, ok := headers["span"]; ok {
+		return context.WithValue(ctx, spanKey{}, span)
+	}
+	return ctx
+}
+
+func TestTracedQueue(t *testing.T) {
+	tracer := &fakeTracer{}
+	q := NewTraced(New[int](), tracer)
+
+	producer := context.WithValue(context.Background(), spanKey{}, "producer")
+	q.Append(producer, 1)
+	q.Append(producer, 2)
+
+	ctx, env, err := q.Pop(context.Background())
+	if err != nil || env.Elem != 1 || env.Attempts != 1 {
+		t.Fatalf("expected element 1 on its first attempt, got %+v, %v", env, err)
+	}
+	if span := ctx.Value(spanKey{}); span != "queue.dequeue#2" {
+		t.Errorf("Pop should return the context of the dequeue span, it holds %v", span)
+	}
+	if _, env, err = q.Reserve(context.Background()); err != nil || env.Elem != 2 {
+		t.Fatalf("expected element 2, got %+v, %v", env, err)
+	}
+
+	want := []string{
+		"producer>queue.enqueue#0",
+		"producer>queue.enqueue#1",
+		"queue.enqueue#0>queue.dequeue#2",
+		"queue.enqueue#1>queue.dequeue#3",
+	}
+	if !reflect.DeepEqual(tracer.spans, want) {
+		t.Errorf("expected spans %v, got %v", want, tracer.spans)
+	}
+
+	q.Queue().Close()
+	if _, _, err := q.Pop(context.Background()); err != ErrClosed {
+		t.Errorf("Pop should return ErrClosed once the queue is closed and empty, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package queue
+
+// noLock is the mutex of a queue created with WithoutLocking, it does nothing
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+// WithoutLocking creates a queue that never locks, for callers that only use it from
+// one goroutine at a time or already serialize access themselves. Nothing can wake
+// a call that waits, so Pop and Take on an empty queue and Append on a full
+// OverflowBlock queue never return. Features that work in the background, such as
+// visibility timeouts and scheduled appends, must not be used either
+func WithoutLocking[T any]() Option[T] {
+	return func(s *settings[T]) {
+		s.unsynchronized = true
+	}
+}
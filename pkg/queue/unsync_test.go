@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithoutLocking(t *testing.T) {
+	q := New[int](WithoutLocking[int]())
+	for i := 0; i < 100; i++ {
+		q.Append(i)
+	}
+	q.Remove(50)
+	for i := 0; i < 49; i++ {
+		if elem := q.Pop(); elem != i {
+			t.Fatalf("expected %d, got %d", i, elem)
+		}
+	}
+	q.Pop()
+	if got := q.ToSlice()[:2]; !reflect.DeepEqual(got, []int{51, 52}) {
+		t.Errorf("expected [51 52], got %v", got)
+	}
+	if q.Length() != 49 {
+		t.Errorf("expected 49 elements, got %d", q.Length())
+	}
+}
+
+func BenchmarkQueueTickTockWithoutLocking(b *testing.B) {
+	q := New[int](WithoutLocking[int]())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Append(i)
+		q.Pop()
+	}
+}
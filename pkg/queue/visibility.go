@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultVisibilityTimeout is how long a reserved element stays hidden unless
+// the queue was created with WithVisibilityTimeout
+const DefaultVisibilityTimeout = 30 * time.Second
+
+// ErrNotReserved is returned by Ack and Nack for a Handle that is not reserved,
+// for instance because its visibility timeout has already run out
+var ErrNotReserved = errors.New("queue: element is not reserved")
+
+type reservation[T any] struct {
+	elem T
+	meta metadata
+	// position in the order elements were reserved in
+	seq  uint64
+	stop func() bool
+}
+
+// WithVisibilityTimeout sets how long an element handed out by Reserve stays
+// hidden before it is put back into the queue, unless it is acked in time
+func WithVisibilityTimeout[T any](timeout time.Duration) Option[T] {
+	return func(s *settings[T]) {
+		s.visibility = timeout
+	}
+}
+
+// WithDeadLetter moves an element to dlq instead of delivering it again once it has
+// been reserved maxDeliveries times without being acked. If dlq does not accept
+// it, for instance because it is closed, the element is dropped
+func WithDeadLetter[T any](dlq *Queue[T], maxDeliveries int) Option[T] {
+	return func(s *settings[T]) {
+		s.deadLetter = dlq
+		s.maxDeliveries = maxDeliveries
+	}
+}
+
+// DeadLetter returns the queue set with WithDeadLetter, or nil
+func (q *Queue[T]) DeadLetter() *Queue[T] {
+	return q.deadLetter
+}
+
+// Reserve removes the element at the front of the queue like Take, but keeps it
+// in flight: unless it is acked with the returned Handle within the visibility
+// timeout, it is put back at the front of the queue and delivered again
+func (q *Queue[T]) Reserve() (T, Handle, error) {
+	return q.ReserveContext(context.Background())
+}
+
+// ReserveContext works like Reserve, but gives up with ctx.Err() once ctx is done
+func (q *Queue[T]) ReserveContext(ctx context.Context) (T, Handle, error) {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	e, err := q.reserve(ctx)
+	return e.elem, Handle(e.id), err
+}
+
+func (q *Queue[T]) reserve(ctx context.Context) (entry[T], error) {
+	e, err := q.takeEntry(ctx, q.popFront)
+	if err != nil {
+		return e, err
+	}
+	if q.reserved == nil {
+		q.reserved = make(map[int64]*reservation[T])
+	}
+	e.meta.deliveries++
+	q.reserves++
+	r := &reservation[T]{elem: e.elem, meta: e.meta, seq: q.reserves}
+	r.stop = afterFunc(q.clock, q.visibility, func() {
+		q.expire(e.id, r)
+	})
+	q.reserved[e.id] = r
+	return e, nil
+}
+
+// Ack marks a reserved element as processed, it will not be delivered again
+func (q *Queue[T]) Ack(handle Handle) error {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	r, ok := q.reserved[int64(handle)]
+	if !ok {
+		return ErrNotReserved
+	}
+	r.stop()
+	q.release(int64(handle))
+	return nil
+}
+
+// Nack puts a reserved element back at the front of the queue right away,
+// without waiting for its visibility timeout
+func (q *Queue[T]) Nack(handle Handle) error {
+	q.mutex.Lock()
+	r, ok := q.reserved[int64(handle)]
+	if !ok {
+		q.unlock()
+		return ErrNotReserved
+	}
+	r.stop()
+	q.release(int64(handle))
+	dead := q.redeliver(int64(handle), r)
+	q.unlock()
+
+	if dead {
+		q.deadLetter.Append(r.elem)
+	}
+	return nil
+}
+
+// Reserved returns the number of elements that are reserved and not acked yet
+func (q *Queue[T]) Reserved() int {
+	q.mutex.Lock()
+	defer q.unlock()
+
+	return len(q.reserved)
+}
+
+// expire puts the element back once its visibility timeout has run out,
+// unless r was acked or nacked in the meantime
+func (q *Queue[T]) expire(id int64, r *reservation[T]) {
+	q.mutex.Lock()
+	if q.reserved[id] != r {
+		q.unlock()
+		return
+	}
+	q.release(id)
+	dead := q.redeliver(id, r)
+	q.unlock()
+
+	if dead {
+		q.deadLetter.Append(r.elem)
+	}
+}
+
+// redeliver puts a reserved element back into the queue, or reports that it has
+// to go to the dead-letter queue because it has been delivered too often
+func (q *Queue[T]) redeliver(id int64, r *reservation[T]) (dead bool) {
+	if q.deadLetter != nil && r.meta.deliveries >= q.maxDeliveries {
+		return true
+	}
+	q.requeue(id, r.elem, r.meta)
+	return false
+}
+
+// requeue puts a reserved element back at the front under its old id, so its
+// Handle stays valid. Capacity and deduplication are not checked, an element
+// that was handed out is never lost. A sorted queue puts it back in order
+func (q *Queue[T]) requeue(id int64, elem T, meta metadata) {
+	i := 0
+	if q.cmp != nil {
+		i = q.search(elem, true)
+	}
+	q.place(i, id, elem)
+	q.setMeta(id, meta)
+}
+
+// release ends the reservation of id, the mutex must be held
+func (q *Queue[T]) release(id int64) {
+	delete(q.reserved, id)
+	// WaitUntilEmpty waits for reserved elements as well
+	q.notFull.Broadcast()
+}
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReserveAck(t *testing.T) {
+	q := New[int]()
+	q.Append(1)
+	q.Append(2)
+
+	item, h, err := q.Reserve()
+	if err != nil || item != 1 {
+		t.Fatalf("There should be 1 on reserve, there is %v (%v)", item, err)
+	}
+	if q.Length() != 1 || q.Reserved() != 1 {
+		t.Errorf("Reserved element should be hidden, length is %d, reserved %d", q.Length(), q.Reserved())
+	}
+	if err := q.Ack(h); err != nil {
+		t.Errorf("Ack should succeed, got %v", err)
+	}
+	if err := q.Ack(h); err != ErrNotReserved {
+		t.Errorf("Second ack should return ErrNotReserved, got %v", err)
+	}
+	if q.Reserved() != 0 || q.Front() != 2 {
+		t.Errorf("Acked element should be gone, front is %d", q.Front())
+	}
+}
+
+func TestReserveNack(t *testing.T) {
+	q := New[int]()
+	q.Append(1)
+	q.Append(2)
+
+	_, h, _ := q.Reserve()
+	if err := q.Nack(h); err != nil {
+		t.Errorf("Nack should succeed, got %v", err)
+	}
+	item, h2, _ := q.Reserve()
+	if item != 1 || h2 != h {
+		t.Errorf("Nacked element should be redelivered first with the same handle, got %d", item)
+	}
+	if err := q.Nack(Handle(42)); err != ErrNotReserved {
+		t.Errorf("Nack of an unknown handle should return ErrNotReserved, got %v", err)
+	}
+}
+
+func TestReserveTimeout(t *testing.T) {
+	clock := newFakeClock()
+	q := New[string](WithClock[string](clock), WithVisibilityTimeout[string](time.Minute))
+	q.Append("job")
+
+	_, h, _ := q.Reserve()
+	clock.Advance(time.Second)
+	if q.Length() != 0 {
+		t.Errorf("Element should stay hidden until the timeout, length is %d", q.Length())
+	}
+	clock.Advance(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	item, h2, err := q.ReserveContext(ctx)
+	if err != nil || item != "job" || h2 != h {
+		t.Errorf("Expired element should be delivered again, got %v (%v)", item, err)
+	}
+	if err := q.Ack(h); err != nil {
+		t.Errorf("Ack of the redelivered element should succeed, got %v", err)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	dlq := New[string]()
+	q := New[string](WithDeadLetter(dlq, 2))
+	q.Append("poison")
+	q.Append("fine")
+
+	for i := 0; i < 2; i++ {
+		item, h, _ := q.Reserve()
+		if item != "poison" {
+			t.Fatalf("There should be poison on reserve %d, there is %s", i, item)
+		}
+		q.Nack(h)
+	}
+
+	if q.DeadLetter() != dlq || dlq.Length() != 1 || dlq.Front() != "poison" {
+		t.Errorf("poison should be in the dead-letter queue, it has %v", dlq.ToSlice())
+	}
+	if q.Length() != 1 || q.Front() != "fine" {
+		t.Errorf("Only fine should be left, there is %v", q.ToSlice())
+	}
+}
@@ -0,0 +1,36 @@
+package queue
+
+import "context"
+
+// WaitUntilEmpty blocks until every element has been popped and every reserved
+// element has been acked, or returns ctx.Err() once ctx is done. It keeps waiting
+// after Close, so shutdown can close the queue and then wait for it to drain
+func (q *Queue[T]) WaitUntilEmpty(ctx context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for q.size > 0 || len(q.reserved) > 0 {
+		if err := waitContext(ctx, q.notFull); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForLength blocks until at least n elements are queued, so a consumer can
+// wait for a full batch. It returns ErrClosed if the queue is closed before it
+// holds n elements and ctx.Err() once ctx is done
+func (q *Queue[T]) WaitForLength(ctx context.Context, n int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for q.size < n {
+		if q.closed {
+			return ErrClosed
+		}
+		if err := waitContext(ctx, q.grown); err != nil {
+			return err
+		}
+	}
+	return nil
+}
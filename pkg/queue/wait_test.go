@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitUntilEmpty(t *testing.T) {
+	q := New[int]()
+	if err := q.WaitUntilEmpty(context.Background()); err != nil {
+		t.Errorf("WaitUntilEmpty on an empty queue should return right away, got %v", err)
+	}
+
+	q.Append(1)
+	q.Append(2)
+	q.Close()
+	go func() {
+		q.Pop()
+		_, h, _ := q.Reserve()
+		time.Sleep(10 * time.Millisecond)
+		q.Ack(h)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.WaitUntilEmpty(ctx); err != nil {
+		t.Errorf("WaitUntilEmpty should return once drained, got %v", err)
+	}
+	if q.Reserved() != 0 {
+		t.Error("WaitUntilEmpty should wait for reserved elements to be acked")
+	}
+
+	q = New[int]()
+	q.Append(1)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.WaitUntilEmpty(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitUntilEmpty should time out, got %v", err)
+	}
+}
+
+func TestWaitForLength(t *testing.T) {
+	q := New[int]()
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(time.Millisecond)
+			q.Append(i)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.WaitForLength(ctx, 3); err != nil || q.Length() != 3 {
+		t.Errorf("WaitForLength should return once 3 elements are queued, got %v with %d", err, q.Length())
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Close()
+	}()
+	if err := q.WaitForLength(context.Background(), 5); err != ErrClosed {
+		t.Errorf("WaitForLength should return ErrClosed, got %v", err)
+	}
+	if err := q.WaitForLength(context.Background(), 2); err != nil {
+		t.Errorf("WaitForLength should succeed on a closed queue that is long enough, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package queue
+
+// Watch returns a channel that receives the length of the queue whenever it
+// changes, starting with the current length. A slow receiver does not block the
+// queue, it skips intermediate lengths and gets the latest one. The channel is
+// closed by Close, call Unwatch once it is not needed anymore
+func (q *Queue[T]) Watch() <-chan int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	c := make(chan int, 1)
+	if q.closed {
+		close(c)
+		return c
+	}
+	if q.watchers == nil {
+		q.watchers = make(map[<-chan int]chan int)
+	}
+	q.watchers[c] = c
+	q.watched = q.size
+	c <- q.watched
+	return c
+}
+
+// Unwatch stops sending to a channel returned by Watch
+func (q *Queue[T]) Unwatch(c <-chan int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.watchers, c)
+}
+
+// publish sends the length to the watchers if it changed, the mutex must be held
+func (q *Queue[T]) publish() {
+	if len(q.watchers) == 0 || q.size == q.watched || q.closed {
+		return
+	}
+	q.watched = q.size
+	for _, c := range q.watchers {
+		// replace a length the watcher has not received yet
+		select {
+		case <-c:
+		default:
+		}
+		c <- q.watched
+	}
+}
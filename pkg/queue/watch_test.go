@@ -0,0 +1,48 @@
+package queue
+
+import "testing"
+
+func TestWatch(t *testing.T) {
+	q := New[int]()
+	q.Append(1)
+
+	w := q.Watch()
+	if n := <-w; n != 1 {
+		t.Errorf("Watch should start with the current length 1, got %d", n)
+	}
+	q.Append(2)
+	if n := <-w; n != 2 {
+		t.Errorf("Watch should report length 2, got %d", n)
+	}
+
+	q.Append(3)
+	q.Pop()
+	q.Pop()
+	if n := <-w; n != 1 {
+		t.Errorf("A slow watcher should get the latest length 1, got %d", n)
+	}
+	select {
+	case n := <-w:
+		t.Errorf("Nothing should be pending, got %d", n)
+	default:
+	}
+
+	q.Clean()
+	if n := <-w; n != 0 {
+		t.Errorf("Watch should report length 0 after Clean, got %d", n)
+	}
+	q.Unwatch(w)
+	q.Append(4)
+	select {
+	case n := <-w:
+		t.Errorf("An unwatched channel should not receive, got %d", n)
+	default:
+	}
+
+	w = q.Watch()
+	<-w
+	q.Close()
+	if _, ok := <-w; ok {
+		t.Error("Close should close watch channels")
+	}
+}
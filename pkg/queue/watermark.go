@@ -0,0 +1,52 @@
+package queue
+
+// watermarks fires callbacks when the length of the queue crosses high or low
+type watermarks struct {
+	high, low     int
+	onHigh, onLow func(length int)
+	// whether high was reached and low has not been reached since
+	above bool
+}
+
+// crossing is a watermark that was crossed while the mutex was held
+type crossing struct {
+	high   bool
+	length int
+}
+
+// WithWatermarks calls onHigh once the length of the queue reaches high and onLow
+// once it has dropped back to low. Each is called once per crossing: after onHigh
+// the queue has to drop to low before onHigh can be called again, and the other
+// way around, so a length that hovers around one watermark does not fire it again
+// and again. Either callback may be nil. They run after the queue has been unlocked,
+// in the goroutine whose call changed the length.
+// Panics if low is not below high
+func WithWatermarks[T any](high, low int, onHigh, onLow func(length int)) Option[T] {
+	if low >= high {
+		panic("queue: low watermark must be below the high watermark")
+	}
+	return func(s *settings[T]) {
+		s.watermarks = &watermarks{high: high, low: low, onHigh: onHigh, onLow: onLow}
+	}
+}
+
+// checkWatermarks records a crossing if the length crossed a watermark, the mutex must be held
+func (q *Queue[T]) checkWatermarks() {
+	w := q.watermarks
+	if w == nil {
+		return
+	}
+	length := q.size
+	switch {
+	case !w.above && length >= w.high:
+		w.above = true
+		if w.onHigh != nil {
+			q.crossings = append(q.crossings, crossing{true, length})
+		}
+	case w.above && length <= w.low:
+		w.above = false
+		if w.onLow != nil {
+			q.crossings = append(q.crossings, crossing{false, length})
+		}
+	}
+}
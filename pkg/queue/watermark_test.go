@@ -0,0 +1,46 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithWatermarks(t *testing.T) {
+	var events []int
+	var q *Queue[int]
+	q = New[int](WithWatermarks[int](3, 1,
+		func(length int) {
+			// callbacks run unlocked, so they may use the queue
+			events = append(events, q.Length())
+		},
+		func(length int) {
+			events = append(events, -length)
+		},
+	))
+
+	for i := 0; i < 3; i++ {
+		q.Append(i)
+	}
+	q.Pop()
+	q.Append(3)
+	q.Append(4)
+	q.Pop()
+	q.Pop()
+	q.Remove(3)
+	q.Pop()
+	q.Append(5)
+
+	// the high watermark fires once until the queue has dropped to the low one
+	if want := []int{3, -1}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+}
+
+func TestWithWatermarksPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a low watermark above the high one should panic")
+		}
+	}()
+	WithWatermarks[int](1, 2, nil, nil)
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// WorkerOption configures RunWorkers
+type WorkerOption[T any] func(*workerSettings[T])
+
+type workerSettings[T any] struct {
+	retries int
+	backoff BackoffPolicy
+	onError func(elem T, err error)
+}
+
+// WithRetries makes a worker call fn again for an element it failed on, up to
+// retries more times, waiting as long as backoff says between the attempts
+func WithRetries[T any](retries int, backoff BackoffPolicy) WorkerOption[T] {
+	return func(s *workerSettings[T]) {
+		s.retries = retries
+		s.backoff = backoff
+	}
+}
+
+// WithOnError registers a callback for the elements fn failed on, after the
+// retries if there are any. Without it failed elements are dropped
+func WithOnError[T any](onError func(elem T, err error)) WorkerOption[T] {
+	return func(s *workerSettings[T]) {
+		s.onError = onError
+	}
+}
+
+// PanicError is the error a worker reports for an element fn panicked on
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("queue: worker panicked: %v", e.Value)
+}
+
+// RunWorkers pops the elements in n goroutines and passes each of them to fn.
+// A panic in fn is recovered and treated as an error, see WithRetries and
+// WithOnError for what happens to an element fn fails on. RunWorkers blocks until
+// ctx is done or the queue is closed and empty, then waits for the calls of fn
+// in progress to return. It returns ctx.Err() or nil respectively.
+// Panics if n is not positive
+func (q *Queue[T]) RunWorkers(ctx context.Context, n int, fn func(context.Context, T) error, opts ...WorkerOption[T]) error {
+	if n <= 0 {
+		panic("queue: number of workers must be positive")
+	}
+	var s workerSettings[T]
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				elem, err := q.PopContext(ctx)
+				if err != nil {
+					return
+				}
+				q.work(ctx, elem, fn, &s)
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// work calls fn for elem until it succeeds or runs out of retries
+func (q *Queue[T]) work(ctx context.Context, elem T, fn func(context.Context, T) error, s *workerSettings[T]) {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = call(ctx, elem, fn); err == nil {
+			return
+		}
+		if attempt >= s.retries || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-q.clock.After(s.backoff.Delay(attempt + 1)):
+		case <-ctx.Done():
+		}
+	}
+	if s.onError != nil {
+		s.onError(elem, err)
+	}
+}
+
+// call calls fn for elem, turning a panic into a PanicError
+func call[T any](ctx context.Context, elem T, fn func(context.Context, T) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r}
+		}
+	}()
+	return fn(ctx, elem)
+}
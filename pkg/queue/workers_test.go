@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunWorkers(t *testing.T) {
+	q := New[int]()
+	for i := 1; i <= 100; i++ {
+		q.Append(i)
+	}
+	q.Close()
+
+	var sum int64
+	err := q.RunWorkers(context.Background(), 4, func(_ context.Context, elem int) error {
+		atomic.AddInt64(&sum, int64(elem))
+		return nil
+	})
+	if err != nil || sum != 5050 {
+		t.Errorf("Workers should handle every element and return nil, the sum is %d (%v)", sum, err)
+	}
+}
+
+func TestRunWorkersCancel(t *testing.T) {
+	q := New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	finished := int32(0)
+	go func() {
+		<-started
+		cancel()
+	}()
+	q.Append(1)
+
+	err := q.RunWorkers(ctx, 2, func(ctx context.Context, elem int) error {
+		close(started)
+		<-ctx.Done()
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+	if err != context.Canceled || atomic.LoadInt32(&finished) != 1 {
+		t.Errorf("RunWorkers should wait for the worker and return context.Canceled, got %v", err)
+	}
+}
+
+func TestRunWorkersRetryAndPanic(t *testing.T) {
+	q := New[string]()
+	q.Append("flaky")
+	q.Append("panics")
+	q.Close()
+
+	var mutex sync.Mutex
+	attempts := map[string]int{}
+	failed := map[string]error{}
+	err := q.RunWorkers(context.Background(), 1, func(_ context.Context, elem string) error {
+		mutex.Lock()
+		attempts[elem]++
+		n := attempts[elem]
+		mutex.Unlock()
+		if elem == "panics" {
+			panic("boom")
+		}
+		if n < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, WithRetries[string](2, BackoffPolicy{Base: time.Millisecond}), WithOnError(func(elem string, err error) {
+		failed[elem] = err
+	}))
+
+	if err != nil {
+		t.Errorf("RunWorkers should return nil, got %v", err)
+	}
+	if attempts["flaky"] != 3 || failed["flaky"] != nil {
+		t.Errorf("flaky should succeed on the third attempt, it took %d", attempts["flaky"])
+	}
+	var p *PanicError
+	if attempts["panics"] != 3 || !errors.As(failed["panics"], &p) || p.Value != "boom" {
+		t.Errorf("panics should fail with a PanicError after 3 attempts, got %v after %d", failed["panics"], attempts["panics"])
+	}
+}